@@ -0,0 +1,66 @@
+package reachable
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// IPResult is the outcome of probing one address of a host with ProbeAllIPs.
+type IPResult struct {
+	IP        net.IP        `json:"ip"`
+	Reachable bool          `json:"reachable"`
+	Latency   time.Duration `json:"latency"`
+
+	// Err is the probe error, matching the same sentinel errors as
+	// Status.Err would, or nil if the probe succeeded. Error holds the same
+	// error as a string.
+	Err   error  `json:"-"`
+	Error string `json:"error,omitempty"`
+}
+
+// ProbeAllIPs resolves host and probes port on every A and AAAA record
+// concurrently, in the same way as a Checker with no interface check, and
+// returns one IPResult per address in the order they were resolved. It needs
+// no running Checker, for diagnosing hosts where only some addresses work,
+// such as round-robin or anycast names. If port is empty DefaultPort is
+// used. If ctx has no deadline, resolution and probes together time out
+// after DefaultTimeout. If host cannot be resolved the error matches ErrDNS;
+// a failed probe does not make ProbeAllIPs fail, but is reported in its
+// IPResult.
+func ProbeAllIPs(ctx context.Context, host, port string) ([]IPResult, error) {
+	if port == "" {
+		port = DefaultPort
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout())
+		defer cancel()
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	results := make([]IPResult, len(addrs))
+	var wg sync.WaitGroup
+	for i, a := range addrs {
+		wg.Add(1)
+		go func(i int, ip net.IP) {
+			defer wg.Done()
+			c := &Checker{}
+			var res result
+			start := time.Now()
+			err := classify(c.probeHost(ctx, net.JoinHostPort(ip.String(), port), &res))
+			results[i] = IPResult{IP: ip, Reachable: err == nil, Err: err}
+			if err != nil {
+				results[i].Error = err.Error()
+			} else {
+				results[i].Latency = time.Since(start)
+			}
+		}(i, a.IP)
+	}
+	wg.Wait()
+	return results, nil
+}
@@ -0,0 +1,62 @@
+package reachable
+
+import "time"
+
+// backoff returns the polling interval after the given number of consecutive
+// failures.
+func (c *Checker) backoff(failures int) time.Duration {
+	d := c.Interval
+	if c.BackoffFunc != nil {
+		if next := c.BackoffFunc(failures, d); next > 0 {
+			return next
+		}
+		return d
+	}
+	if c.BackoffMin > 0 && c.BackoffMin < d {
+		limit := d
+		if c.MaxInterval > d {
+			limit = c.MaxInterval
+		}
+		d = c.BackoffMin
+		for i := 1; i < failures && d < limit; i++ {
+			d *= 2
+		}
+		if d > limit {
+			d = limit
+		}
+		return d
+	}
+	if c.MaxInterval <= d {
+		return d
+	}
+	for i := 0; i < failures && d < c.MaxInterval; i++ {
+		d *= 2
+	}
+	if d > c.MaxInterval {
+		d = c.MaxInterval
+	}
+	return d
+}
+
+// checkOutage tracks the current run of failures and fires
+// OnSustainedOutage when backoff reaches its cap.
+func (c *Checker) checkOutage(res result) {
+	if res.ok {
+		c.outageStart = time.Time{}
+		c.outageSignalled = false
+		return
+	}
+	if c.outageStart.IsZero() {
+		c.outageStart = c.clock()
+	}
+	if c.outageSignalled || (c.MaxInterval <= c.Interval && c.BackoffFunc == nil) || c.MaxInterval <= 0 {
+		return
+	}
+	if c.backoff(c.Status().ConsecutiveCount) >= c.MaxInterval {
+		c.outageSignalled = true
+		if c.OnSustainedOutage != nil {
+			since := c.outageStart
+			c.dispatch(func() { c.OnSustainedOutage(since) })
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package reachable
+
+// CircuitBreaker is the part of a circuit breaker that DriveBreaker needs:
+// a way to force it open, failing calls fast, and to close it again.
+type CircuitBreaker interface {
+	Open()
+	Close()
+}
+
+// DriveBreaker opens b whenever the host becomes unreachable and closes it
+// when the host is reachable again, so that calls to a dependency fail fast
+// during an outage instead of each waiting out a timeout. It maps the
+// Notifier's view of the host: Up and Degraded close b, Down and
+// CaptivePortal open it. Once a check has completed b is set to the current
+// state at once, and it is otherwise left alone until the first check. The
+// returned function stops driving b, leaving it as it is. DriveBreaker is
+// safe to call while the Checker is running, but not from a notifier.
+//
+// Breakers with other method names are adapted with a small wrapper:
+//
+//    type breaker struct{ cb *mylib.Breaker }
+//
+//    func (b breaker) Open()  { b.cb.ForceOpen() }
+//    func (b breaker) Close() { b.cb.Reset() }
+//
+//    c := &reachable.Checker{Hostport: "payments.internal:443"}
+//    c.Start()
+//    stop := c.DriveBreaker(breaker{cb})
+//    defer stop()
+func (c *Checker) DriveBreaker(b CircuitBreaker) (remove func()) {
+	return c.AddNotifierWithCurrent(func(reachable bool) {
+		if reachable {
+			b.Close()
+		} else {
+			b.Open()
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package reachable
+
+// subscriberBuffer is how many undelivered State changes a subscriber channel
+// holds. When it is full the oldest change is dropped, so a slow receiver may
+// miss intermediate states but always ends up with the latest.
+const subscriberBuffer = 8
+
+// Updates returns a channel of the Checker's State transitions and a function
+// to cancel the subscription, which closes the channel.
+//
+// Transitions are buffered, so the channel never blocks the Checker. If the
+// receiver falls behind the oldest buffered transition is dropped, so
+// intermediate states may be missed but the latest one is always delivered.
+// When the Checker stops the channel is closed after any buffered
+// transitions, which can still be received in order, including the final
+// State. A receive that reports the channel closed therefore always means
+// the Checker stopped or the subscription was cancelled, never a State
+// change. If the Checker is not running the channel is returned closed.
+func (c *Checker) Updates() (<-chan State, func()) {
+	return c.subscribe()
+}
+
+// subscribe returns a new channel of State changes, closed when the Checker
+// stops, and a function to unsubscribe. If the Checker is not running the
+// channel is returned already closed.
+func (c *Checker) subscribe() (<-chan State, func()) {
+	ch := make(chan State, subscriberBuffer)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		close(ch)
+		return ch, func() {}
+	}
+	if c.subs == nil {
+		c.subs = make(map[chan State]struct{})
+	}
+	c.subs[ch] = struct{}{}
+	return ch, func() {
+		c.mu.Lock()
+		if _, ok := c.subs[ch]; ok {
+			delete(c.subs, ch)
+			close(ch)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// broadcast sends s to every subscriber without blocking.
+func (c *Checker) broadcast(s State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ch := range c.subs {
+		for sent := false; !sent; {
+			select {
+			case ch <- s:
+				sent = true
+			default:
+				// full: drop the oldest change to make room
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// closeSubscribers closes every subscriber channel.
+func (c *Checker) closeSubscribers() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ch := range c.subs {
+		delete(c.subs, ch)
+		close(ch)
+	}
+}
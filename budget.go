@@ -0,0 +1,122 @@
+package reachable
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Rough per-probe traffic, in bytes including IP and TCP headers, used to
+// estimate data use for MaxBytesPerHour.
+const (
+	// tcpBytes covers the three-way handshake and teardown, about seven
+	// segments.
+	tcpBytes = 420
+
+	// dnsBytes covers one A or AAAA query and its answer.
+	dnsBytes = 200
+
+	// tlsBytes covers a TLS handshake, dominated by the certificate chain.
+	tlsBytes = 6000
+
+	// httpBytes covers request and response headers.
+	httpBytes = 800
+)
+
+// estimateBytes returns the estimated data use of one check. It counts the
+// first host of Hostports only, as checks usually stop there, and nothing
+// for PingFunc and InterfaceOnly probes, whose traffic is unknown or nil.
+func (c *Checker) estimateBytes() int64 {
+	switch {
+	case c.InterfaceOnly, c.PingFunc != nil:
+		return 0
+	case c.ConnFactory != nil:
+		return c.tcpEstimate("")
+	case c.CaptivePortal:
+		return tcpBytes + dnsBytes + httpBytes + int64(len(c.captiveCheck().body))
+	}
+	hosts := c.hostports()
+	if len(hosts) == 0 {
+		return 0
+	}
+	n := c.hostEstimate(hosts[0])
+	if len(c.Ports) > 0 {
+		n = dnsBytes + int64(len(c.Ports))*(n-dnsBytes)
+	}
+	if len(c.Interfaces) > 0 {
+		n *= int64(len(c.Interfaces))
+	}
+	return n
+}
+
+// hostEstimate estimates a probe of a single Hostport entry.
+func (c *Checker) hostEstimate(hostport string) int64 {
+	if !strings.Contains(hostport, "://") {
+		return c.tcpEstimate(hostport)
+	}
+	u, err := url.Parse(hostport)
+	if err != nil {
+		return 0
+	}
+	n := c.tcpEstimate(u.Host)
+	switch u.Scheme {
+	case "tls":
+		n += tlsBytes
+	case "https":
+		n += tlsBytes
+		fallthrough
+	case "http":
+		n += httpBytes
+		if c.HealthJSON {
+			n += maxHealthBody
+		} else if c.HTTPMethod != "" && c.HTTPMethod != "HEAD" {
+			n += maxBody
+		}
+	}
+	return n
+}
+
+// tcpEstimate estimates a TCP probe of hostport, including the lookup unless
+// its host is an IP address, and any payload.
+func (c *Checker) tcpEstimate(hostport string) int64 {
+	n := int64(tcpBytes + len(c.Send) + len(c.Expect))
+	if c.LargeProbeSize > 0 {
+		n += 2 * int64(c.LargeProbeSize)
+	}
+	if c.ProxyProtocol != 0 {
+		n += 100
+	}
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	if host != "" && net.ParseIP(host) == nil {
+		n += dnsBytes
+	}
+	return n
+}
+
+// withinBudget reports whether a check estimated at n bytes fits within
+// MaxBytesPerHour, and if so deducts it. The budget is a bucket holding up to
+// an hour's allowance, refilled continuously.
+func (c *Checker) withinBudget(n int64) bool {
+	if c.MaxBytesPerHour <= 0 {
+		return true
+	}
+	now := c.clock()
+	limit := float64(c.MaxBytesPerHour)
+	if c.budgetAt.IsZero() {
+		c.budget = limit
+	} else {
+		c.budget += now.Sub(c.budgetAt).Hours() * limit
+		if c.budget > limit {
+			c.budget = limit
+		}
+	}
+	c.budgetAt = now
+	if float64(n) > c.budget {
+		return false
+	}
+	c.budget -= float64(n)
+	return true
+}
@@ -0,0 +1,71 @@
+package reachable
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+)
+
+// captiveCheck is a well-known captive portal detection URL and the exact
+// response it returns when nothing intercepts the request.
+type captiveCheck struct {
+	url    string
+	status int
+	body   string
+}
+
+// captiveChecks are the platform detection URLs used by default, keyed by
+// GOOS. Other platforms use the Android one.
+var captiveChecks = map[string]captiveCheck{
+	"android": {"http://connectivitycheck.gstatic.com/generate_204", http.StatusNoContent, ""},
+	"darwin":  {"http://captive.apple.com/hotspot-detect.html", http.StatusOK, "<HTML><HEAD><TITLE>Success</TITLE></HEAD><BODY>Success</BODY></HTML>\n"},
+	"ios":     {"http://captive.apple.com/hotspot-detect.html", http.StatusOK, "<HTML><HEAD><TITLE>Success</TITLE></HEAD><BODY>Success</BODY></HTML>\n"},
+	"windows": {"http://www.msftconnecttest.com/connecttest.txt", http.StatusOK, "Microsoft Connect Test"},
+	"linux":   {"http://nmcheck.gnome.org/check_network_status.txt", http.StatusOK, "NetworkManager is online\n"},
+}
+
+// captiveCheck returns the detection URL and expected response to use.
+func (c *Checker) captiveCheck() captiveCheck {
+	if c.CaptivePortalURL != "" {
+		if c.CaptivePortalResponse == "" {
+			return captiveCheck{c.CaptivePortalURL, http.StatusNoContent, ""}
+		}
+		return captiveCheck{c.CaptivePortalURL, http.StatusOK, c.CaptivePortalResponse}
+	}
+	if cc, ok := captiveChecks[runtime.GOOS]; ok {
+		return cc
+	}
+	return captiveChecks["android"]
+}
+
+// probeCaptive fetches the captive portal detection URL without following
+// redirects. Any response other than the expected one means the request was
+// intercepted.
+func (c *Checker) probeCaptive(ctx context.Context, res *result) error {
+	cc := c.captiveCheck()
+	res.host = cc.url
+	if err := c.checkVhost(""); err != nil {
+		return err
+	}
+	u, err := url.Parse(cc.url)
+	if err != nil {
+		return &classError{ErrConfig, err}
+	}
+	status, body, err := c.httpRequest(ctx, http.MethodGet, u, res, false, maxBody)
+	if err != nil {
+		return err
+	}
+	var mismatch string
+	switch {
+	case status != cc.status:
+		mismatch = fmt.Sprintf("status %d instead of %d", status, cc.status)
+	case string(body) != cc.body:
+		mismatch = fmt.Sprintf("an unexpected %d byte body", len(body))
+	default:
+		return nil
+	}
+	res.captive = true
+	return &classError{ErrCaptivePortal, fmt.Errorf("reachable: %s returned %s, captive portal suspected", u.Redacted(), mismatch)}
+}
@@ -0,0 +1,45 @@
+package reachable
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCaptivePortal(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		problem string // a substring of the error, or "" if not intercepted
+	}{
+		{"expected", http.StatusOK, "online", ""},
+		{"redirected", http.StatusFound, "online", "status 302 instead of 200"},
+		{"login page", http.StatusOK, "<html>sign in</html>", "an unexpected 20 byte body"},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		c := &Checker{
+			CaptivePortal:         true,
+			CaptivePortalURL:      srv.URL,
+			CaptivePortalResponse: "online",
+			SkipInterfaceCheck:    true,
+		}
+		st := c.Step()
+		srv.Close()
+		if tt.problem == "" {
+			if st.State != Up {
+				t.Errorf("%s: %v with %v, want up", tt.name, st.State, st.Err)
+			}
+			continue
+		}
+		if st.State != CaptivePortal || !errors.Is(st.Err, ErrCaptivePortal) || !strings.Contains(st.Err.Error(), tt.problem) {
+			t.Errorf("%s: %v with %v, want a captive portal with %q", tt.name, st.State, st.Err, tt.problem)
+		}
+	}
+}
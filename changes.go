@@ -0,0 +1,36 @@
+//go:build go1.23
+
+package reachable
+
+import (
+	"context"
+	"iter"
+)
+
+// Changes returns an iterator over the Checker's State transitions, for use
+// with range:
+//
+//    for s := range c.Changes(ctx) {
+//        log.Println("now", s)
+//    }
+//
+// The iterator yields each new State until ctx is cancelled, the Checker
+// stops, or the loop exits. A slow loop body may miss intermediate states but
+// always sees the latest one. The Checker must be running when iteration
+// starts.
+func (c *Checker) Changes(ctx context.Context) iter.Seq[State] {
+	return func(yield func(State) bool) {
+		ch, unsubscribe := c.subscribe()
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s, ok := <-ch:
+				if !ok || !yield(s) {
+					return
+				}
+			}
+		}
+	}
+}
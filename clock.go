@@ -0,0 +1,48 @@
+package reachable
+
+import (
+	"context"
+	"time"
+)
+
+// clock returns the current time from Clock, or the system clock.
+func (c *Checker) clock() time.Time {
+	if c.Clock != nil {
+		return c.Clock()
+	}
+	return time.Now()
+}
+
+// Step runs a single polling cycle synchronously, as if Interval had elapsed,
+// and returns the resulting Status. Together with PingFunc and Clock it lets a
+// test feed the Checker a scripted sequence of probe outcomes, such as down,
+// down, up, down, up, up, and assert exactly which notifications fire for
+// given settings:
+//
+//    var outcomes []error // scripted results, one per Step
+//    c := &reachable.Checker{
+//        SkipInterfaceCheck: true,
+//        PingFunc: func(context.Context) error {
+//            err := outcomes[0]
+//            outcomes = outcomes[1:]
+//            return err
+//        },
+//        Clock:    func() time.Time { return now },
+//        Notifier: func(r bool) { got = append(got, r) },
+//    }
+//    for range outcomes {
+//        c.Step()
+//        now = now.Add(time.Minute)
+//    }
+//
+// Step must not be used on a Checker that has been started. Callbacks are
+// called before it returns unless Dispatch is set.
+func (c *Checker) Step() Status {
+	if !c.stepping {
+		c.stepping = true
+		c.ctx = context.Background()
+		c.begin()
+	}
+	c.tick(c.ctx, false)
+	return c.Status()
+}
@@ -0,0 +1,140 @@
+package reachable
+
+// Clone returns a new, stopped Checker with the same configuration as c but
+// checking hostport instead. Hostports and Quorum are cleared in the clone.
+// Running state, status and statistics are not copied, so the clone can be
+// started independently of c; Tags is copied. Callbacks, NotifyChan, Dialer,
+// BaseContext, TLSConfig, Resolver, DebugWriter and Rand are shared with c;
+// see the Rand documentation before sharing it between Checkers.
+func (c *Checker) Clone(hostport string) *Checker {
+	c.mu.Lock()
+	notifier := c.Notifier // may be replaced by SetNotifier
+	c.mu.Unlock()
+	return &Checker{
+		Hostport:              hostport,
+		Name:                  c.Name,
+		Tags:                  copyTags(c.Tags),
+		Ports:                 append([]int(nil), c.Ports...),
+		CaptivePortal:         c.CaptivePortal,
+		CaptivePortalURL:      c.CaptivePortalURL,
+		CaptivePortalResponse: c.CaptivePortalResponse,
+		TLSConfig:             c.TLSConfig,
+		ExpectALPN:            c.ExpectALPN,
+		DialHost:              c.DialHost,
+		HostHeader:            c.HostHeader,
+		HTTPMethod:            c.HTTPMethod,
+		DisableGETFallback:    c.DisableGETFallback,
+		AcceptStatus:          append([]int(nil), c.AcceptStatus...),
+		NoRedirects:           c.NoRedirects,
+		HealthJSON:            c.HealthJSON,
+		RotateHosts:           c.RotateHosts,
+		RotateFailover:        c.RotateFailover,
+		ShuffleHosts:          c.ShuffleHosts,
+		Interval:              c.Interval,
+		Notifier:              notifier,
+		NotifierCtx:           c.NotifierCtx,
+		NotifyChan:            c.NotifyChan,
+		ReasonNotifier:        c.ReasonNotifier,
+		SkipInitialNotify:     c.SkipInitialNotify,
+		AlwaysNotify:          c.AlwaysNotify,
+		MinNotifyInterval:     c.MinNotifyInterval,
+		MaxNotifyDelay:        c.MaxNotifyDelay,
+		OnTransition:          c.OnTransition,
+		Significant:           c.Significant,
+		OnDown:                c.OnDown,
+		OnFirstReachable:      c.OnFirstReachable,
+		OnReachableAgain:      c.OnReachableAgain,
+		OnAddressChange:       c.OnAddressChange,
+		OnHostNotFound:        c.OnHostNotFound,
+		HostNotFoundInterval:  c.HostNotFoundInterval,
+		DependsOn:             c.DependsOn,
+		Dispatch:              c.Dispatch,
+		DegradedThreshold:     c.DegradedThreshold,
+		MaxLatency:            c.MaxLatency,
+		LatencySmoothing:      c.LatencySmoothing,
+		SuppressWindows:       append([]TimeWindow(nil), c.SuppressWindows...),
+		SkipProbesInWindows:   c.SkipProbesInWindows,
+		ConnFactory:           c.ConnFactory,
+		Dialer:                c.Dialer,
+		PingFunc:              c.PingFunc,
+		SkipInterfaceCheck:    c.SkipInterfaceCheck,
+		InterfaceGate:         c.InterfaceGate,
+		InterfaceCacheTTL:     c.InterfaceCacheTTL,
+		IncludeLoopback:       c.IncludeLoopback,
+		IsMetered:             c.IsMetered,
+		Interfaces:            append([]string(nil), c.Interfaces...),
+		InterfaceOnly:         c.InterfaceOnly,
+		FailOpen:              c.FailOpen,
+		MaxBytesPerHour:       c.MaxBytesPerHour,
+		Enabled:               c.Enabled,
+		ShouldCheck:           c.ShouldCheck,
+		ShouldCheckInterval:   c.ShouldCheckInterval,
+		Freshness:             c.Freshness,
+		ResolveTimeout:        c.ResolveTimeout,
+		ConnectTimeout:        c.ConnectTimeout,
+		CheckBudget:           c.CheckBudget,
+		Network:               c.Network,
+		HappyEyeballs:         c.HappyEyeballs,
+		RetryFreshDNS:         c.RetryFreshDNS,
+		Resolver:              c.Resolver,
+		ExpectResolvesTo:      append([]string(nil), c.ExpectResolvesTo...),
+		Send:                  cloneBytes(c.Send),
+		Expect:                cloneBytes(c.Expect),
+		LargeProbeSize:        c.LargeProbeSize,
+		Inspect:               c.Inspect,
+		ConnectProxy:          c.ConnectProxy,
+		ProxyProtocol:         c.ProxyProtocol,
+		ReuseConn:             c.ReuseConn,
+		ReuseMaxAge:           c.ReuseMaxAge,
+		LocalPortMin:          c.LocalPortMin,
+		LocalPortMax:          c.LocalPortMax,
+		RefusedIsReachable:    c.RefusedIsReachable,
+		ReachableOnErrors:     append([]error(nil), c.ReachableOnErrors...),
+		ReachableOnError:      c.ReachableOnError,
+		ConfirmUpAfter:        c.ConfirmUpAfter,
+		StickyDuration:        c.StickyDuration,
+		NoRouteImmediate:      c.NoRouteImmediate,
+		BaseContext:           c.BaseContext,
+		Timeout:               c.Timeout,
+		ProbeWatchdog:         c.ProbeWatchdog,
+		OnProbeStart:          c.OnProbeStart,
+		OnProbeEnd:            c.OnProbeEnd,
+		OnProbeResult:         c.OnProbeResult,
+		OnTrace:               c.OnTrace,
+		RunFor:                c.RunFor,
+		OnExpire:              c.OnExpire,
+		MaxFailures:           c.MaxFailures,
+		GiveUpOnHostNotFound:  c.GiveUpOnHostNotFound,
+		OnGiveUp:              c.OnGiveUp,
+		CheckOnResume:         c.CheckOnResume,
+		FlapHistory:           c.FlapHistory,
+		ConfidenceWindow:      c.ConfidenceWindow,
+		TrendWindow:           c.TrendWindow,
+		OnDegrading:           c.OnDegrading,
+		DegradingSlope:        c.DegradingSlope,
+		Clock:                 c.Clock,
+		DebugWriter:           c.DebugWriter,
+		MaxInterval:           c.MaxInterval,
+		BackoffMin:            c.BackoffMin,
+		BackoffFunc:           c.BackoffFunc,
+		FastStartProbes:       c.FastStartProbes,
+		FastStartInterval:     c.FastStartInterval,
+		OnSustainedOutage:     c.OnSustainedOutage,
+		DownReminderInterval:  c.DownReminderInterval,
+		OnDownReminder:        c.OnDownReminder,
+		NextInterval:          c.NextInterval,
+		Pool:                  c.Pool,
+		AlignToClock:          c.AlignToClock,
+		Jitter:                c.Jitter,
+		JitterStrategy:        c.JitterStrategy,
+		Rand:                  c.Rand,
+	}
+}
+
+// cloneBytes copies b, preserving the distinction between nil and empty.
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
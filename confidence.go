@@ -0,0 +1,43 @@
+package reachable
+
+// DefaultConfidenceWindow is how many recent check results Confidence is
+// computed from when Checker.ConfidenceWindow is unset.
+const DefaultConfidenceWindow = 10
+
+// Confidence returns a score from 0 to 1 of how well the most recent checks
+// agree with the current Status.Reachable: the fraction of the last
+// ConfidenceWindow checks, or of all checks since Start if fewer, whose probe
+// result matches it. A host that passed or failed every recent check scores
+// 1, while on a noisy link where half the probes fail it scores about 0.5,
+// and lower still if StickyDuration or ConfirmUpAfter hold a State that
+// recent probes disagree with. It is 0 before the first check completes.
+func (c *Checker) Confidence() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.recent) == 0 || c.status.State == Unknown {
+		return 0
+	}
+	agree := 0
+	for _, ok := range c.recent {
+		if ok == c.status.Reachable {
+			agree++
+		}
+	}
+	return float64(agree) / float64(len(c.recent))
+}
+
+// addSample records a probe result for Confidence. c.mu must be held.
+func (c *Checker) addSample(ok bool) {
+	n := c.ConfidenceWindow
+	if n <= 0 {
+		n = DefaultConfidenceWindow
+	}
+	if c.recent == nil {
+		c.recent = make([]bool, 0, n)
+	}
+	if len(c.recent) >= n {
+		copy(c.recent, c.recent[len(c.recent)-n+1:])
+		c.recent = c.recent[:n-1]
+	}
+	c.recent = append(c.recent, ok)
+}
@@ -0,0 +1,144 @@
+package reachable
+
+import (
+	"strings"
+	"time"
+)
+
+// Config is a Checker's configuration after all defaults and package-level
+// fallbacks have been applied, as returned by EffectiveConfig.
+type Config struct {
+	Name string
+
+	// Probe names the kind of probe used: "tcp", "http", "tls", "ports",
+	// "conn-factory", "ping", "rotate", "quorum", "interface" or
+	// "captive-portal", or the URL scheme of a probe registered with
+	// RegisterScheme.
+	Probe string
+
+	// Hosts are the targets probed, with DefaultPort applied, and Quorum
+	// how many of them must be reachable, or zero for any.
+	Hosts  []string
+	Ports  []int
+	Quorum int
+
+	Interval time.Duration
+	Jitter   time.Duration
+
+	// MaxInterval is zero unless backoff is enabled, and BackoffMin unless
+	// faster polling while down is.
+	MaxInterval time.Duration
+	BackoffMin  time.Duration
+
+	// Timeout is the overall deadline for each probe. ResolveTimeout and
+	// ConnectTimeout are zero unless DNS resolution is done as a separate
+	// phase.
+	Timeout        time.Duration
+	ResolveTimeout time.Duration
+	ConnectTimeout time.Duration
+
+	Network         string
+	InterfaceCheck  bool
+	InterfaceGate   string
+	IncludeLoopback bool
+	HTTPMethod      string
+
+	DegradedThreshold time.Duration
+	MaxLatency        time.Duration
+	LatencySmoothing  float64
+	Freshness         time.Duration
+	StickyDuration    time.Duration
+	ConfirmUpAfter    time.Duration
+
+	// ReuseMaxAge is zero unless ReuseConn is set.
+	ReuseMaxAge time.Duration
+}
+
+// EffectiveConfig returns the configuration c will actually use, after
+// applying defaults such as DefaultInterval and DefaultTimeout. It may be
+// called before Start, and is useful for debugging unexpected behavior and
+// validating configuration.
+func (c *Checker) EffectiveConfig() Config {
+	cfg := Config{
+		Name:              c.name(),
+		Probe:             "tcp",
+		Ports:             append([]int(nil), c.Ports...),
+		Interval:          orDefault(c.Interval, defaultInterval()),
+		Jitter:            c.Jitter,
+		Timeout:           c.timeout(),
+		Network:           c.network(),
+		InterfaceCheck:    c.interfaceGate() != InterfaceGateOff,
+		InterfaceGate:     c.interfaceGate().String(),
+		IncludeLoopback:   c.IncludeLoopback,
+		HTTPMethod:        c.HTTPMethod,
+		DegradedThreshold: c.DegradedThreshold,
+		MaxLatency:        c.MaxLatency,
+		LatencySmoothing:  c.LatencySmoothing,
+		Freshness:         orDefault(c.Freshness, DefaultFreshness),
+		StickyDuration:    c.StickyDuration,
+		ConfirmUpAfter:    c.ConfirmUpAfter,
+	}
+	if cfg.HTTPMethod == "" {
+		cfg.HTTPMethod = "HEAD"
+	}
+	if cfg.LatencySmoothing <= 0 || cfg.LatencySmoothing > 1 {
+		cfg.LatencySmoothing = DefaultLatencySmoothing
+	}
+	if c.ResolveTimeout > 0 || c.ConnectTimeout > 0 || c.CheckBudget > 0 {
+		cfg.ResolveTimeout = c.resolveTimeout(defaultTimeout())
+		cfg.ConnectTimeout = c.connectTimeout(defaultTimeout())
+	}
+	if c.MaxInterval > cfg.Interval {
+		cfg.MaxInterval = c.MaxInterval
+	}
+	if c.BackoffMin > 0 && c.BackoffMin < cfg.Interval {
+		cfg.BackoffMin = c.BackoffMin
+	}
+	if c.ReuseConn {
+		cfg.ReuseMaxAge = orDefault(c.ReuseMaxAge, DefaultReuseMaxAge)
+	}
+
+	for _, hp := range c.hostports() {
+		if strings.Contains(hp, "://") {
+			scheme := strings.ToLower(hp[:strings.Index(hp, "://")])
+			if probe, _, ok := registeredScheme(scheme); ok {
+				if probe != nil {
+					cfg.Probe = scheme
+				}
+			} else if scheme == "http" || scheme == "https" {
+				cfg.Probe = "http"
+			} else if scheme == "tls" {
+				cfg.Probe = "tls"
+			}
+			cfg.Hosts = append(cfg.Hosts, hp)
+			continue
+		}
+		cfg.Hosts = append(cfg.Hosts, withDefaultPort(hp))
+	}
+	switch {
+	case c.InterfaceOnly:
+		cfg.Probe = "interface"
+		cfg.Hosts = nil
+		cfg.Ports = nil
+		cfg.InterfaceCheck = true
+		cfg.InterfaceGate = InterfaceGateBlock.String()
+	case c.PingFunc != nil:
+		cfg.Probe = "ping"
+		cfg.Hosts = nil
+	case c.ConnFactory != nil:
+		cfg.Probe = "conn-factory"
+		cfg.Hosts = nil
+	case c.CaptivePortal:
+		cfg.Probe = "captive-portal"
+		cfg.Hosts = []string{c.captiveCheck().url}
+		cfg.Ports = nil
+	case len(c.Ports) > 0:
+		cfg.Probe = "ports"
+	case c.Quorum > 0:
+		cfg.Probe = "quorum"
+		cfg.Quorum = c.Quorum
+	case c.RotateHosts && len(cfg.Hosts) > 1:
+		cfg.Probe = "rotate"
+	}
+	return cfg
+}
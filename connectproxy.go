@@ -0,0 +1,95 @@
+package reachable
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// connectProxy parses ConnectProxy.
+func (c *Checker) connectProxy() (*url.URL, error) {
+	u, err := url.Parse(c.ConnectProxy)
+	if err != nil {
+		return nil, &classError{ErrConfig, err}
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, &classError{ErrConfig, fmt.Errorf("reachable: ConnectProxy %q is not an http or https URL", u.Redacted())}
+	}
+	return u, nil
+}
+
+// tunnel connects to hostport through ConnectProxy with an HTTP CONNECT
+// request, and returns the tunnelled connection once the proxy accepts it.
+func (c *Checker) tunnel(ctx context.Context, hostport string, res *result) (net.Conn, error) {
+	u, err := c.connectProxy()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	conn, err := c.dial(ctx, res, net.Dialer{}, "tcp", urlHostport(u))
+	if err != nil {
+		res.connectTime = time.Since(start)
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if u.Scheme == "https" {
+		tconn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tconn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tconn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: hostport},
+		Host:   hostport,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	res.connectTime = time.Since(start)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, &classError{ErrProxy, fmt.Errorf("reachable: proxy %s answered CONNECT %s with %s",
+			u.Host, hostport, resp.Status)}
+	}
+	if br.Buffered() > 0 {
+		// the host already sent data through the tunnel
+		return &bufferedConn{conn, br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn whose reads drain r first.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (b *bufferedConn) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
@@ -0,0 +1,73 @@
+package reachable
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// fakeProxy returns the address of an HTTP proxy that answers the first
+// request on each connection with reply, sent verbatim, and passes the
+// request to requests.
+func fakeProxy(t *testing.T, reply string, requests chan<- *http.Request) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil {
+					return
+				}
+				requests <- req
+				conn.Write([]byte(reply))
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestConnectProxy(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		up    bool
+		err   error // the error a failed check matches, if any in particular
+	}{
+		{"established", "HTTP/1.1 200 Connection established\r\n\r\nhello", true, nil},
+		{"auth required", "HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 0\r\n\r\n", false, ErrProxy},
+		{"bad gateway", "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n", false, ErrProxy},
+		{"not http", "SSH-2.0-OpenSSH\r\n", false, nil},
+	}
+	for _, tt := range tests {
+		requests := make(chan *http.Request, 1)
+		c := &Checker{
+			Hostport:           "example.com:443",
+			ConnectProxy:       "http://user:secret@" + fakeProxy(t, tt.reply, requests),
+			Expect:             []byte("hello"),
+			SkipInterfaceCheck: true,
+		}
+		if st := c.Step(); (st.State == Up) != tt.up || (tt.err != nil && !errors.Is(st.Err, tt.err)) {
+			t.Errorf("%s: %v with %v, want up %v with %v", tt.name, st.State, st.Err, tt.up, tt.err)
+		}
+
+		req := <-requests
+		if req.Method != http.MethodConnect || req.RequestURI != "example.com:443" || req.Host != "example.com:443" {
+			t.Errorf("%s: proxy got %s %s for host %s, want CONNECT example.com:443", tt.name, req.Method, req.RequestURI, req.Host)
+		}
+		if got, want := req.Header.Get("Proxy-Authorization"), "Basic dXNlcjpzZWNyZXQ="; got != want {
+			t.Errorf("%s: Proxy-Authorization %q, want %q", tt.name, got, want)
+		}
+	}
+}
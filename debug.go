@@ -0,0 +1,45 @@
+package reachable
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// debugMu serializes writes to every Checker's DebugWriter.
+var debugMu sync.Mutex
+
+// debugLog writes a line describing res to DebugWriter, if set.
+func (c *Checker) debugLog(res result) {
+	if c.DebugWriter == nil {
+		return
+	}
+	outcome := "down"
+	if res.ok {
+		outcome = "up"
+	}
+	host := res.host
+	if host == "" {
+		host = "-"
+	}
+	line := fmt.Sprintf("%s %s %s %s %s", time.Now().Format(time.RFC3339), c.name(), host, outcome, res.latency)
+	if res.err != nil {
+		line += fmt.Sprintf(" err=%q", res.err.Error())
+	}
+
+	debugMu.Lock()
+	fmt.Fprintln(c.DebugWriter, line)
+	debugMu.Unlock()
+}
+
+// debugf writes a formatted message line to DebugWriter, if set.
+func (c *Checker) debugf(format string, args ...interface{}) {
+	if c.DebugWriter == nil {
+		return
+	}
+	line := fmt.Sprintf("%s %s %s", time.Now().Format(time.RFC3339), c.name(), fmt.Sprintf(format, args...))
+
+	debugMu.Lock()
+	fmt.Fprintln(c.DebugWriter, line)
+	debugMu.Unlock()
+}
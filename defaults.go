@@ -0,0 +1,42 @@
+package reachable
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Overrides of DefaultInterval and DefaultTimeout set with SetDefaultInterval
+// and SetDefaultTimeout, in nanoseconds, or zero if unset. Accessed
+// atomically.
+var (
+	defaultIntervalNS int64
+	defaultTimeoutNS  int64
+)
+
+// SetDefaultInterval replaces DefaultInterval for all Checkers. Unlike
+// assigning DefaultInterval, it is safe to call while Checkers are running.
+// Once set, the value takes precedence over DefaultInterval; a non-positive d
+// clears it again.
+func SetDefaultInterval(d time.Duration) {
+	atomic.StoreInt64(&defaultIntervalNS, int64(d))
+}
+
+// SetDefaultTimeout replaces DefaultTimeout for all Checkers, in the same way
+// as SetDefaultInterval.
+func SetDefaultTimeout(d time.Duration) {
+	atomic.StoreInt64(&defaultTimeoutNS, int64(d))
+}
+
+func defaultInterval() time.Duration {
+	if d := time.Duration(atomic.LoadInt64(&defaultIntervalNS)); d > 0 {
+		return d
+	}
+	return DefaultInterval
+}
+
+func defaultTimeout() time.Duration {
+	if d := time.Duration(atomic.LoadInt64(&defaultTimeoutNS)); d > 0 {
+		return d
+	}
+	return DefaultTimeout
+}
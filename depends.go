@@ -0,0 +1,41 @@
+package reachable
+
+import "fmt"
+
+// dependencyErr returns ErrDependencyDown if the DependsOn chain finds a
+// lower layer unreachable, or ErrConfig if the chain loops.
+func (c *Checker) dependencyErr() error {
+	if c.dependencyCycle() {
+		return &classError{ErrConfig, fmt.Errorf("reachable: DependsOn chain of %s forms a cycle", c.name())}
+	}
+	dep := c.DependsOn
+	if dep == nil {
+		return nil
+	}
+	if st := dep.Status().State; st == Down || st == CaptivePortal {
+		return &classError{ErrDependencyDown, fmt.Errorf("reachable: dependency %s is %v", dep.name(), st)}
+	}
+	return nil
+}
+
+// dependencyCycle reports whether following DependsOn from c loops.
+func (c *Checker) dependencyCycle() bool {
+	seen := map[*Checker]bool{c: true}
+	for dep := c.DependsOn; dep != nil; dep = dep.DependsOn {
+		if seen[dep] {
+			return true
+		}
+		seen[dep] = true
+	}
+	return false
+}
+
+// wakeDependents makes every running Checker that depends on c check at once,
+// now that c finds its host reachable again.
+func wakeDependents(c *Checker) {
+	for _, d := range Running() {
+		if d.DependsOn == c {
+			d.CheckNow()
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package reachable
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"time"
+)
+
+// Dialer makes the network connections of a Checker's probes. *net.Dialer,
+// *tls.Dialer and most proxy dialers implement it. See Checker.Dialer.
+type Dialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+func (c *Checker) resolver() *net.Resolver {
+	if c.Resolver != nil {
+		return c.Resolver
+	}
+	return net.DefaultResolver
+}
+
+// timeout returns the overall deadline for a single probe.
+func (c *Checker) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	if c.CheckBudget > 0 {
+		return c.CheckBudget
+	}
+	if c.ResolveTimeout > 0 || c.ConnectTimeout > 0 {
+		return c.resolveTimeout(defaultTimeout()) + c.connectTimeout(defaultTimeout())
+	}
+	return defaultTimeout()
+}
+
+// Shares of CheckBudget, in percent, given to the DNS lookup and the connect
+// phases. The rest is left for handshakes and the exchange.
+const (
+	budgetResolveShare = 30
+	budgetConnectShare = 40
+)
+
+// resolveTimeout returns ResolveTimeout, or else its share of CheckBudget,
+// or else def.
+func (c *Checker) resolveTimeout(def time.Duration) time.Duration {
+	if c.ResolveTimeout <= 0 && c.CheckBudget > 0 {
+		return c.CheckBudget * budgetResolveShare / 100
+	}
+	return orDefault(c.ResolveTimeout, def)
+}
+
+// connectTimeout returns ConnectTimeout, or else its share of CheckBudget,
+// or else def.
+func (c *Checker) connectTimeout(def time.Duration) time.Duration {
+	if c.ConnectTimeout <= 0 && c.CheckBudget > 0 {
+		return c.CheckBudget * budgetConnectShare / 100
+	}
+	return orDefault(c.ConnectTimeout, def)
+}
+
+func (c *Checker) network() string {
+	if c.Network == "" {
+		return "tcp"
+	}
+	return c.Network
+}
+
+// lookup resolves host with r to addresses of the family selected by Network.
+func (c *Checker) lookup(ctx context.Context, r *net.Resolver, host string) ([]string, error) {
+	var ipnet string
+	switch c.network() {
+	case "tcp4":
+		ipnet = "ip4"
+	case "tcp6":
+		ipnet = "ip6"
+	default:
+		return r.LookupHost(ctx, host)
+	}
+	ips, err := r.LookupIP(ctx, ipnet, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = ip.String()
+	}
+	return addrs, nil
+}
+
+// connect dials hostport, resolving it as a separate step first when
+// ResolveTimeout, ConnectTimeout, CheckBudget, Network or ExpectResolvesTo
+// call for it, or tunnels to it through ConnectProxy.
+func (c *Checker) connect(ctx context.Context, hostport string, res *result) (net.Conn, error) {
+	if c.ConnectProxy != "" {
+		return c.tunnel(ctx, hostport, res)
+	}
+	if c.ResolveTimeout > 0 || c.ConnectTimeout > 0 || c.CheckBudget > 0 || c.network() != "tcp" || len(c.ExpectResolvesTo) > 0 {
+		return c.resolveAndConnect(ctx, c.resolver(), hostport, res)
+	}
+	start := time.Now()
+	conn, err := c.dial(ctx, res, net.Dialer{}, "tcp", hostport)
+	res.connectTime = time.Since(start)
+	return conn, err
+}
+
+// freshResolver queries the configured name servers directly, bypassing any
+// caching done by the operating system's resolver.
+var freshResolver = &net.Resolver{PreferGo: true}
+
+// redialFresh retries a failed probe of hostport after a fresh DNS lookup,
+// in case the host's address changed and a stale cached entry was used.
+func (c *Checker) redialFresh(ctx context.Context, hostport string, res *result) error {
+	r := freshResolver
+	if c.Resolver != nil && c.Resolver.PreferGo {
+		r = c.Resolver
+	}
+	return c.resolveAndDialWith(ctx, r, hostport, res)
+}
+
+func (c *Checker) resolveAndDialWith(ctx context.Context, r *net.Resolver, hostport string, res *result) error {
+	conn, err := c.resolveAndConnect(ctx, r, hostport, res)
+	if err != nil {
+		return err
+	}
+	return c.finish(ctx, conn, res)
+}
+
+// resolveAndConnect looks up the host in hostport with r within
+// ResolveTimeout, then tries each resolved address in turn until one connects
+// or ConnectTimeout expires, timing each phase. Both phases are also bounded
+// by the deadline of ctx.
+func (c *Checker) resolveAndConnect(ctx context.Context, r *net.Resolver, hostport string, res *result) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	rctx, cancel := context.WithTimeout(ctx, c.resolveTimeout(defaultTimeout()))
+	addrs, err := c.lookup(rctx, r, host)
+	cancel()
+	res.dnsTime = time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	res.resolved = addrs
+	if err := c.checkResolved(host, addrs); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	defer func() { res.connectTime = time.Since(start) }()
+	dctx, cancel := context.WithTimeout(ctx, c.connectTimeout(defaultTimeout()))
+	defer cancel()
+	for _, addr := range addrs {
+		var conn net.Conn
+		conn, err = c.dial(dctx, res, net.Dialer{}, c.network(), net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		if dctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// orDefault returns d if it is positive, otherwise def.
+func orDefault(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
+
+// finish completes a probe over an established conn and closes it.
+func (c *Checker) finish(ctx context.Context, conn net.Conn, res *result) error {
+	defer conn.Close()
+	res.addr = conn.RemoteAddr()
+	if err := c.sendProxyHeader(ctx, conn); err != nil {
+		return err
+	}
+	if c.Send != nil || c.Expect != nil {
+		if err := c.exchange(ctx, conn); err != nil {
+			return err
+		}
+	}
+	if c.LargeProbeSize > 0 {
+		if err := largeEcho(ctx, conn, c.LargeProbeSize); err != nil {
+			res.largeErr = &classError{ErrLargeProbe, err}
+		}
+	}
+	if c.Inspect != nil {
+		return c.Inspect(conn)
+	}
+	return nil
+}
+
+// largeEcho writes size bytes to conn and requires them to be echoed back.
+func largeEcho(ctx context.Context, conn net.Conn, size int) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	payload := make([]byte, size)
+	for i := range payload {
+		payload[i] = byte('A' + i%26)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(payload)
+		errc <- err
+	}()
+	echo := make([]byte, size)
+	if _, err := io.ReadFull(conn, echo); err != nil {
+		return err
+	}
+	if err := <-errc; err != nil {
+		return err
+	}
+	if !bytes.Equal(echo, payload) {
+		return ErrUnexpectedResponse
+	}
+	return nil
+}
+
+// exchange writes Send to conn and waits for the Expect response.
+func (c *Checker) exchange(ctx context.Context, conn net.Conn) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if len(c.Send) > 0 {
+		if _, err := conn.Write(c.Send); err != nil {
+			return err
+		}
+	}
+	buf := make([]byte, len(c.Expect))
+	if len(buf) == 0 {
+		buf = make([]byte, 1)
+	}
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+	if len(c.Expect) > 0 && !bytes.Equal(buf, c.Expect) {
+		return ErrUnexpectedResponse
+	}
+	return nil
+}
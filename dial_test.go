@@ -0,0 +1,88 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// silentListener returns the address of a listener that accepts connections
+// and then never responds on them.
+func silentListener(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, c := range conns {
+				c.Close()
+			}
+			close(done)
+		}()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	t.Cleanup(func() {
+		l.Close()
+		<-done
+	})
+	return l.Addr().String()
+}
+
+// dialFunc adapts a function to the Dialer interface.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func (f dialFunc) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return f(ctx, network, addr)
+}
+
+// hang blocks until ctx is done, like an endpoint that never answers.
+func hang(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestTimeoutIsOverallDeadline(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	silent := silentListener(t)
+	tests := []struct {
+		name string
+		c    *Checker
+		err  error
+	}{
+		{"tls handshake", &Checker{Hostport: "tls://" + silent}, ErrTimeout},
+		{"http response", &Checker{Hostport: "http://" + silent}, ErrTimeout},
+		{"tcp connect", &Checker{Hostport: "example.com:80", Dialer: dialFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, hang(ctx)
+		})}, ErrTimeout},
+		{"dns", &Checker{Hostport: "example.com:80", Network: "tcp4", Resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return nil, hang(ctx)
+			},
+		}}, ErrDNS},
+	}
+	for _, tt := range tests {
+		tt.c.Timeout = timeout
+		tt.c.SkipInterfaceCheck = true
+		start := time.Now()
+		st := tt.c.Step()
+		if took := time.Since(start); took > 5*timeout {
+			t.Errorf("%s: check took %v with Timeout %v", tt.name, took, timeout)
+		}
+		if st.State != Down || !errors.Is(st.Err, tt.err) {
+			t.Errorf("%s: %v with %v, want down with %v", tt.name, st.State, st.Err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Package dohprobe checks that DNS over HTTPS (RFC 8484) works end to end,
+// which matters on networks that block or tamper with plain DNS. A probe
+// resolves a name through the configured DoH endpoint, and succeeds only if
+// the answer came back over HTTPS.
+//
+//    p := &dohprobe.Probe{Resolver: dohprobe.Resolver{URL: "https://cloudflare-dns.com/dns-query"}}
+//    c := reachable.Checker{
+//        Hostport: "cloudflare-dns.com:443",
+//        PingFunc: p.Ping,
+//    }
+//    c.Start()
+//
+// The same Resolver can be used for a Checker's own lookups through
+// Checker.Resolver, so that probes of other hosts resolve them over DoH too:
+//
+//    r := &dohprobe.Resolver{URL: "https://dns.google/dns-query"}
+//    c := reachable.Checker{Hostport: "example.com:443", Resolver: r.NetResolver()}
+//
+// Queries are sent with the standard library's net/http, so the endpoint is
+// verified as usual for HTTPS, and a proxy can be set through Resolver.Client.
+package dohprobe
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultTimeout bounds a Check whose context has no deadline.
+	DefaultTimeout = time.Second * 5
+
+	// DefaultName is resolved by a Probe without a Name.
+	DefaultName = "example.com."
+)
+
+// ErrNotDoH is returned by Check when the name was resolved without asking
+// the DoH endpoint, e.g. from the hosts file.
+var ErrNotDoH = errors.New("dohprobe: name not resolved over DoH")
+
+// maxMessage is the largest DNS message that can be framed for the resolver.
+const maxMessage = 65535
+
+// Resolver sends DNS queries to a DoH endpoint.
+type Resolver struct {
+	// URL is the DoH endpoint, e.g. "https://cloudflare-dns.com/dns-query".
+	// Queries are POSTed to it in DNS wire format.
+	URL string
+
+	// Client makes the HTTPS requests. If nil, http.DefaultClient is used,
+	// which takes its proxy from the environment.
+	Client *http.Client
+}
+
+// NetResolver returns a net.Resolver that sends all its DNS queries to the
+// endpoint, in place of the system's name servers. Names in the hosts file
+// are still resolved from it.
+func (r *Resolver) NetResolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return &conn{ctx: ctx, r: r}, nil
+		},
+	}
+}
+
+// exchange POSTs the DNS query msg to the endpoint and returns its answer.
+func (r *Resolver) exchange(ctx context.Context, msg []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", r.URL, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dohprobe: %s: HTTP status %s", r.URL, resp.Status)
+	}
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, maxMessage+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(answer) < 12 || len(answer) > maxMessage {
+		return nil, fmt.Errorf("dohprobe: %s: invalid DNS answer of %d bytes", r.URL, len(answer))
+	}
+	return answer, nil
+}
+
+// Result describes a successful probe.
+type Result struct {
+	// Addrs are the addresses Name resolved to.
+	Addrs []net.IP
+
+	// Latency is how long the resolution took, including all the DoH
+	// queries it needed.
+	Latency time.Duration
+}
+
+// Probe resolves Name over DoH. Its Ping method can be used directly as a
+// reachable.Checker PingFunc.
+type Probe struct {
+	Resolver
+
+	// Name is the host name to resolve, which should exist and not be in
+	// the hosts file. A trailing dot avoids the search domains. If empty,
+	// uses DefaultName.
+	Name string
+}
+
+// Ping resolves Name and returns nil if it was answered over DoH. It has the
+// signature of a reachable.Checker PingFunc.
+func (p *Probe) Ping(ctx context.Context) error {
+	_, err := p.Check(ctx)
+	return err
+}
+
+// Check resolves Name through the endpoint. If a DoH query failed, the error
+// is that of the query rather than the resolver's more generic one.
+func (p *Probe) Check(ctx context.Context) (Result, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	name := p.Name
+	if name == "" {
+		name = DefaultName
+	}
+
+	tr := &trace{}
+	start := time.Now()
+	addrs, err := p.NetResolver().LookupIPAddr(context.WithValue(ctx, traceKey{}, tr), name)
+	latency := time.Since(start)
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if err != nil {
+		if tr.err != nil {
+			return Result{}, tr.err
+		}
+		return Result{}, err
+	}
+	if tr.answers == 0 {
+		return Result{}, ErrNotDoH
+	}
+	res := Result{Latency: latency}
+	for _, a := range addrs {
+		res.Addrs = append(res.Addrs, a.IP)
+	}
+	return res, nil
+}
+
+// traceKey is the context key for the trace of a Check.
+type traceKey struct{}
+
+// trace records the outcome of the DoH queries made for a Check, which
+// the resolver may send concurrently.
+type trace struct {
+	mu      sync.Mutex
+	answers int
+	err     error
+}
+
+// conn is the connection the resolver believes it has to a name server. It
+// uses DNS over TCP framing, a two byte length before each message, and each
+// complete query written to it is exchanged with the endpoint at once, with
+// the answer queued for reading.
+type conn struct {
+	ctx      context.Context
+	r        *Resolver
+	deadline time.Time
+	wbuf     []byte
+	rbuf     bytes.Buffer
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	c.wbuf = append(c.wbuf, b...)
+	for len(c.wbuf) >= 2 {
+		n := int(binary.BigEndian.Uint16(c.wbuf))
+		if len(c.wbuf) < 2+n {
+			break
+		}
+		msg := c.wbuf[2 : 2+n]
+		c.wbuf = c.wbuf[2+n:]
+		if err := c.exchange(msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// exchange sends msg to the endpoint, queueing the framed answer and
+// recording the outcome in the Check's trace, if any.
+func (c *conn) exchange(msg []byte) error {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	answer, err := c.r.exchange(ctx, msg)
+	if tr, ok := c.ctx.Value(traceKey{}).(*trace); ok {
+		tr.mu.Lock()
+		if err != nil {
+			tr.err = err
+		} else {
+			tr.answers++
+		}
+		tr.mu.Unlock()
+	}
+	if err != nil {
+		return err
+	}
+	var n [2]byte
+	binary.BigEndian.PutUint16(n[:], uint16(len(answer)))
+	c.rbuf.Write(n[:])
+	c.rbuf.Write(answer)
+	return nil
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	if c.rbuf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.rbuf.Read(b)
+}
+
+func (c *conn) Close() error                       { return nil }
+func (c *conn) LocalAddr() net.Addr                { return addr("") }
+func (c *conn) RemoteAddr() net.Addr               { return addr(c.r.URL) }
+func (c *conn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *conn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *conn) SetWriteDeadline(t time.Time) error { c.deadline = t; return nil }
+
+// addr is the DoH endpoint URL as a net.Addr.
+type addr string
+
+func (a addr) Network() string { return "https" }
+func (a addr) String() string  { return string(a) }
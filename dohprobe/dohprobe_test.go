@@ -0,0 +1,98 @@
+package dohprobe
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// tlsServer starts an HTTPS server for h, without logging the handshakes
+// the resolver abandons when it has its answer.
+func tlsServer(t *testing.T, h http.Handler) *httptest.Server {
+	srv := httptest.NewUnstartedServer(h)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// endpoint returns a DoH server answering A queries with 192.0.2.1 and any
+// other type with no records, and a Resolver for it. queries counts the
+// queries it was sent.
+func endpoint(t *testing.T, queries *int32) Resolver {
+	t.Helper()
+	srv := tlsServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/dns-message" {
+			t.Errorf("got %s with Content-Type %q, want a POST of application/dns-message", r.Method, r.Header.Get("Content-Type"))
+		}
+		msg, _ := io.ReadAll(r.Body)
+		// the question ends after the name's labels, its type and class
+		end := 12
+		for end < len(msg) && msg[end] != 0 {
+			end += int(msg[end]) + 1
+		}
+		end += 5
+		if len(msg) < 12 || end > len(msg) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(queries, 1)
+		qtype := uint16(msg[end-4])<<8 | uint16(msg[end-3])
+		resp := append([]byte(nil), msg[:end]...)
+		resp[2], resp[3] = 0x81, 0x80 // response, RD, RA
+		resp[4], resp[5] = 0, 1       // one question
+		for i := 6; i < 12; i++ {
+			resp[i] = 0
+		}
+		if qtype == 1 {
+			resp[7] = 1 // one answer
+			resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 192, 0, 2, 1)
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(resp)
+	}))
+	return Resolver{URL: srv.URL, Client: srv.Client()}
+}
+
+func TestCheck(t *testing.T) {
+	var queries int32
+	p := &Probe{Resolver: endpoint(t, &queries), Name: "probe.test."}
+	res, err := p.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Addrs) != 1 || !res.Addrs[0].Equal(net.IPv4(192, 0, 2, 1)) {
+		t.Errorf("Addrs %v, want [192.0.2.1]", res.Addrs)
+	}
+	if atomic.LoadInt32(&queries) == 0 {
+		t.Error("the DoH endpoint was not queried")
+	}
+}
+
+func TestNetResolver(t *testing.T) {
+	var queries int32
+	r := endpoint(t, &queries)
+	addrs, err := r.NetResolver().LookupHost(context.Background(), "host.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+		t.Errorf("LookupHost() = %v, want [192.0.2.1]", addrs)
+	}
+}
+
+func TestCheckHTTPError(t *testing.T) {
+	srv := tlsServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no", http.StatusBadGateway)
+	}))
+	p := &Probe{Resolver: Resolver{URL: srv.URL, Client: srv.Client()}, Name: "probe.test."}
+	if err := p.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Ping() = %v, want the endpoint's 502 status", err)
+	}
+}
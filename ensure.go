@@ -0,0 +1,42 @@
+package reachable
+
+import "time"
+
+// DefaultFreshness is how recent a check must be for EnsureReachable to
+// trust it, when Checker.Freshness is unset.
+var DefaultFreshness = time.Second * 10
+
+// EnsureReachable gives just-in-time confirmation that the host is reachable,
+// e.g. right before an operation that needs the network. If the last check
+// completed within Freshness, its result is returned immediately. Otherwise a
+// check is triggered with CheckNow and EnsureReachable waits up to timeout for
+// it, returning false if it does not complete in time. If the Checker is not
+// running, or is paused or disabled by Enabled so that no check would be made,
+// the state last found is returned immediately instead.
+func (c *Checker) EnsureReachable(timeout time.Duration) bool {
+	freshness := orDefault(c.Freshness, DefaultFreshness)
+	c.mu.Lock()
+	st := c.status
+	if c.checked == nil {
+		c.checked = make(chan struct{})
+	}
+	checked := c.checked
+	running := c.running && !c.stopping
+	c.mu.Unlock()
+	if !st.LastCheck.IsZero() && c.clock().Sub(st.LastCheck) <= freshness {
+		return st.State.reachable()
+	}
+	if !running || c.Paused() || (c.Enabled != nil && !c.Enabled()) {
+		return st.State.reachable()
+	}
+
+	c.CheckNow()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-checked:
+		return c.Status().State.reachable()
+	case <-t.C:
+		return false
+	}
+}
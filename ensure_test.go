@@ -0,0 +1,60 @@
+package reachable
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEnsureReachableWhenNoCheckWillRun(t *testing.T) {
+	enabled := int32(1)
+	c := pinged(ok)
+	c.Freshness = time.Nanosecond
+	c.Enabled = func() bool { return atomic.LoadInt32(&enabled) == 1 }
+	c.Start()
+	defer c.StopAndWait()
+	if err := c.WaitFirstCheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Pause()
+	within(t, time.Second, "EnsureReachable while paused", func() {
+		if !c.EnsureReachable(time.Minute) {
+			t.Error("EnsureReachable while paused = false, want the held up state")
+		}
+	})
+	c.Resume()
+
+	atomic.StoreInt32(&enabled, 0)
+	within(t, time.Second, "EnsureReachable while disabled", func() {
+		if !c.EnsureReachable(time.Minute) {
+			t.Error("EnsureReachable while disabled = false, want the held up state")
+		}
+	})
+
+	c.StopAndWait()
+	within(t, time.Second, "EnsureReachable after Stop", func() {
+		if !c.EnsureReachable(time.Minute) {
+			t.Error("EnsureReachable after Stop = false, want the last state")
+		}
+	})
+}
+
+func TestEnsureReachableWaitsForCheck(t *testing.T) {
+	c := pinged(ok)
+	c.Freshness = time.Nanosecond
+	c.Interval = time.Hour
+	c.Start()
+	defer c.StopAndWait()
+	if err := c.WaitFirstCheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checks := c.Stats().Checks
+	if !c.EnsureReachable(5 * time.Second) {
+		t.Error("EnsureReachable = false, want true")
+	}
+	if c.Stats().Checks <= checks {
+		t.Error("EnsureReachable with a stale result did not trigger a check")
+	}
+}
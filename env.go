@@ -0,0 +1,54 @@
+package reachable
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultEnvHost is the host checked by StartFromEnv when REACHABLE_HOST is
+// not set.
+var DefaultEnvHost = "google.com"
+
+// StartFromEnv is like Start, but takes its configuration from the
+// environment, which is convenient for containerized deployments:
+//
+//    REACHABLE_HOST      hostport to check (default DefaultEnvHost)
+//    REACHABLE_INTERVAL  polling interval, e.g. "30s" (default DefaultInterval)
+//    REACHABLE_TIMEOUT   probe timeout, e.g. "5s" (default DefaultTimeout)
+//
+// Durations use time.ParseDuration syntax. If any variable is invalid an error
+// is returned and the default Checker is not started.
+func StartFromEnv() error {
+	host := os.Getenv("REACHABLE_HOST")
+	if host == "" {
+		host = DefaultEnvHost
+	}
+	interval, err := envDuration("REACHABLE_INTERVAL", defaultInterval())
+	if err != nil {
+		return err
+	}
+	timeout, err := envDuration("REACHABLE_TIMEOUT", 0)
+	if err != nil {
+		return err
+	}
+	startDefault(host, interval, timeout, true)
+	return nil
+}
+
+// envDuration parses a positive duration from the named variable, returning
+// def if it is unset.
+func envDuration(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("reachable: invalid %s: %v", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("reachable: invalid %s: must be positive", name)
+	}
+	return d, nil
+}
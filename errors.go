@@ -0,0 +1,253 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrNoInterface means no active non-loopback network interface was
+	// found, so no probe was attempted.
+	ErrNoInterface = errors.New("reachable: no network interface up")
+
+	// ErrInterfaceList means the network interfaces could not be listed, so
+	// the interface check could not be made. See Checker.FailOpen.
+	ErrInterfaceList = errors.New("reachable: listing network interfaces failed")
+
+	// ErrRefused means the host actively refused the connection.
+	ErrRefused = errors.New("reachable: connection refused")
+
+	// ErrNoRoute means the operating system reported that it has no route
+	// to the host or its network (EHOSTUNREACH or ENETUNREACH). Unlike a
+	// timeout this is known at once, and is a definite sign of a
+	// disconnected network. See Checker.NoRouteImmediate.
+	ErrNoRoute = errors.New("reachable: no route to host")
+
+	// ErrTimeout means the probe did not complete before its deadline.
+	ErrTimeout = errors.New("reachable: timed out")
+
+	// ErrDNS means the host name could not be resolved.
+	ErrDNS = errors.New("reachable: DNS lookup failed")
+
+	// ErrHostNotFound means DNS reported that the host name does not exist
+	// (NXDOMAIN), which usually points to a typo in the configuration
+	// rather than an outage. Errors matching it also match ErrDNS. See
+	// Checker.OnHostNotFound.
+	ErrHostNotFound = errors.New("reachable: no such host")
+
+	// ErrConfig means the Checker is misconfigured, e.g. Hostport is not a
+	// valid URL. Retrying will not help.
+	ErrConfig = errors.New("reachable: invalid configuration")
+
+	// ErrProxy means Checker.ConnectProxy refused to open a tunnel to the
+	// host, e.g. because it requires authentication or the host is blocked.
+	ErrProxy = errors.New("reachable: proxy refused tunnel")
+
+	// ErrHTTPStatus means an HTTP probe got a response with an unacceptable
+	// status code.
+	ErrHTTPStatus = errors.New("reachable: bad HTTP status")
+
+	// ErrHealthWarn and ErrHealthFail mean that a Checker.HealthJSON probe
+	// got a health response with the status "warn" or "fail", and
+	// ErrHealthResponse that the response was not valid health JSON.
+	ErrHealthWarn     = errors.New("reachable: health status warn")
+	ErrHealthFail     = errors.New("reachable: health status fail")
+	ErrHealthResponse = errors.New("reachable: malformed health response")
+
+	// ErrCaptivePortal means the captive portal detection URL did not return
+	// its expected response, so requests are being intercepted. See
+	// Checker.CaptivePortal.
+	ErrCaptivePortal = errors.New("reachable: captive portal detected")
+
+	// ErrTLSHandshake means a tls:// or https:// probe connected to the host
+	// but the TLS handshake failed, as happens behind broken SSL inspection
+	// or with an untrusted certificate. The network path works, but TLS
+	// traffic on it does not. See Status.TLSFailed.
+	ErrTLSHandshake = errors.New("reachable: TLS handshake failed")
+
+	// ErrALPN means a TLS probe did not negotiate Checker.ExpectALPN.
+	ErrALPN = errors.New("reachable: TLS protocol not negotiated")
+
+	// ErrLargeProbe means the probe connected but a large payload did not
+	// make the round trip, which suggests an MTU black hole. See
+	// Checker.LargeProbeSize.
+	ErrLargeProbe = errors.New("reachable: large probe failed")
+
+	// ErrUnexpectedAddress means the host resolved to an address outside
+	// Checker.ExpectResolvesTo, and was not probed.
+	ErrUnexpectedAddress = errors.New("reachable: resolved to unexpected address")
+
+	// ErrUnexpectedResponse is returned by a probe when the host responded to
+	// Checker.Send with data that does not match Checker.Expect.
+	ErrUnexpectedResponse = errors.New("reachable: unexpected response")
+
+	// ErrTooSlow means the probe succeeded but took longer than
+	// Checker.MaxLatency.
+	ErrTooSlow = errors.New("reachable: latency above maximum")
+
+	// ErrNetworkUnreachable is returned by a Transport for requests it
+	// refuses to send because its Checker finds the host unreachable.
+	ErrNetworkUnreachable = errors.New("reachable: network unreachable")
+
+	// ErrProbeStuck means a probe did not return until well after its
+	// deadline, and was abandoned by Checker.ProbeWatchdog so that checking
+	// could go on. It points to a PingFunc, ConnFactory or other callback
+	// that ignores its context.
+	ErrProbeStuck = errors.New("reachable: probe stuck past its deadline")
+
+	// ErrDependencyDown means the host was not probed because the
+	// Checker.DependsOn Checker finds its own host unreachable.
+	ErrDependencyDown = errors.New("reachable: dependency down")
+
+	// ErrAlreadyRunning is returned by StartDefault when the default Checker
+	// is already running for a different host.
+	ErrAlreadyRunning = errors.New("reachable: already running")
+
+	// ErrNotRunning is returned when waiting on a Checker that has not been
+	// started, or that stopped while waiting.
+	ErrNotRunning = errors.New("reachable: checker not running")
+)
+
+// classError tags an underlying probe error with one of the package's
+// sentinel errors, so that both can be matched with errors.Is.
+type classError struct {
+	class error
+	err   error
+}
+
+func (e *classError) Error() string        { return e.err.Error() }
+func (e *classError) Unwrap() error        { return e.err }
+func (e *classError) Is(target error) bool { return target == e.class }
+
+// classify wraps a raw probe error with its sentinel class, if it has one.
+func classify(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return &classError{ErrRefused, err}
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return &classError{ErrNoRoute, err}
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsNotFound {
+			return &classError{ErrHostNotFound, &classError{ErrDNS, err}}
+		}
+		return &classError{ErrDNS, err}
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &classError{ErrTimeout, err}
+	}
+	return err
+}
+
+// Cause is a coarse category of why a host was found unreachable.
+type Cause int
+
+const (
+	// CauseOther is any failure not covered by another Cause.
+	CauseOther Cause = iota
+
+	// CauseNoInterface means no local network interface was up.
+	CauseNoInterface
+
+	// CauseDNS means the host name could not be resolved.
+	CauseDNS
+
+	// CauseTimeout means the probe timed out, got stuck, or exceeded
+	// MaxLatency.
+	CauseTimeout
+
+	// CauseRefused means the connection was refused.
+	CauseRefused
+
+	// CauseNoRoute means there was no route to the host.
+	CauseNoRoute
+)
+
+func (c Cause) String() string {
+	switch c {
+	case CauseNoInterface:
+		return "no interface"
+	case CauseDNS:
+		return "dns"
+	case CauseTimeout:
+		return "timeout"
+	case CauseRefused:
+		return "refused"
+	case CauseNoRoute:
+		return "no route"
+	}
+	return "other"
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding a Cause as its
+// String form.
+func (c Cause) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *Cause) UnmarshalText(text []byte) error {
+	for _, x := range []Cause{CauseOther, CauseNoInterface, CauseDNS, CauseTimeout, CauseRefused, CauseNoRoute} {
+		if string(text) == x.String() {
+			*c = x
+			return nil
+		}
+	}
+	return fmt.Errorf("reachable: unknown cause %q", text)
+}
+
+// causeOf maps a classified probe error to its Cause.
+func causeOf(err error) Cause {
+	switch {
+	case errors.Is(err, ErrNoInterface), errors.Is(err, ErrInterfaceList):
+		return CauseNoInterface
+	case errors.Is(err, ErrDNS):
+		return CauseDNS
+	case errors.Is(err, ErrTimeout), errors.Is(err, ErrTooSlow), errors.Is(err, ErrProbeStuck):
+		return CauseTimeout
+	case errors.Is(err, ErrRefused):
+		return CauseRefused
+	case errors.Is(err, ErrNoRoute):
+		return CauseNoRoute
+	}
+	return CauseOther
+}
+
+// reasons are the sentinel errors Reason describes, most specific first.
+var reasons = []error{
+	ErrNoInterface, ErrInterfaceList, ErrRefused, ErrNoRoute, ErrHostNotFound,
+	ErrUnexpectedAddress, ErrDNS, ErrTLSHandshake, ErrTimeout, ErrProbeStuck, ErrTooSlow, ErrProxy, ErrCaptivePortal,
+	ErrHTTPStatus, ErrHealthFail, ErrHealthWarn, ErrHealthResponse, ErrALPN,
+	ErrLargeProbe, ErrUnexpectedResponse, ErrDependencyDown, ErrConfig,
+}
+
+// Reason returns a short human-readable description of a check outcome, such
+// as "connection refused", "DNS lookup failed" or "ok, 42ms". For an error
+// matching one of the package's Err values it is that value's message,
+// without the "reachable: " prefix; other errors are described by their
+// own message. A nil err is described as "ok" with latency, if at least a
+// millisecond.
+func Reason(err error, latency time.Duration) string {
+	if err == nil {
+		if latency = latency.Round(time.Millisecond); latency > 0 {
+			return "ok, " + latency.String()
+		}
+		return "ok"
+	}
+	err = classify(err)
+	for _, r := range reasons {
+		if errors.Is(err, r) {
+			return strings.TrimPrefix(r.Error(), "reachable: ")
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,241 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// failing returns a PingFunc failing with err.
+func failing(err error) func(context.Context) error {
+	return func(context.Context) error { return err }
+}
+
+func TestDownCause(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Cause
+	}{
+		{"dns", &net.DNSError{Err: "server misbehaving", Name: "example.com"}, CauseDNS},
+		{"host not found", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, CauseDNS},
+		{"timeout", fmt.Errorf("dial: %w", context.DeadlineExceeded), CauseTimeout},
+		{"refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, CauseRefused},
+		{"host unreachable", &net.OpError{Op: "dial", Err: syscall.EHOSTUNREACH}, CauseNoRoute},
+		{"network unreachable", &net.OpError{Op: "dial", Err: syscall.ENETUNREACH}, CauseNoRoute},
+		{"other", errors.New("broken"), CauseOther},
+	}
+	for _, tt := range tests {
+		c := pinged(failing(tt.err))
+		if st := c.Step(); st.State != Down || st.DownCause != tt.want {
+			t.Errorf("%s: %v with cause %v, want down with %v", tt.name, st.State, st.DownCause, tt.want)
+		}
+		c.PingFunc = ok
+		if st := c.Step(); st.State != Up || st.DownCause != CauseOther {
+			t.Errorf("%s: cause %v once up, want it cleared", tt.name, st.DownCause)
+		}
+	}
+}
+
+func TestDownCauseNoInterface(t *testing.T) {
+	c := &Checker{Hostport: "example.com:80", InterfaceCacheTTL: time.Hour}
+	c.stepping, c.ctx = true, context.Background()
+	c.begin()
+	c.ifaceScanned = time.Now() // a cached scan finding none up
+	if st := c.Step(); st.State != Down || st.DownCause != CauseNoInterface || !errors.Is(st.Err, ErrNoInterface) {
+		t.Errorf("%v with cause %v and %v, want down with no interface", st.State, st.DownCause, st.Err)
+	}
+}
+
+func TestCauseOf(t *testing.T) {
+	for err, want := range map[error]Cause{
+		ErrNoInterface:   CauseNoInterface,
+		ErrInterfaceList: CauseNoInterface,
+		ErrDNS:           CauseDNS,
+		ErrTimeout:       CauseTimeout,
+		ErrTooSlow:       CauseTimeout,
+		ErrProbeStuck:    CauseTimeout,
+		ErrRefused:       CauseRefused,
+		ErrNoRoute:       CauseNoRoute,
+		ErrHTTPStatus:    CauseOther,
+	} {
+		if got := causeOf(err); got != want {
+			t.Errorf("causeOf(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+func TestCauseText(t *testing.T) {
+	for _, c := range []Cause{CauseOther, CauseNoInterface, CauseDNS, CauseTimeout, CauseRefused, CauseNoRoute} {
+		text, err := c.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Cause
+		if err := got.UnmarshalText(text); err != nil || got != c {
+			t.Errorf("%v round-tripped to %v, %v", c, got, err)
+		}
+	}
+	var c Cause
+	if err := c.UnmarshalText([]byte("bogus")); err == nil {
+		t.Error("unknown cause accepted")
+	}
+}
+
+// nxdomain returns a Resolver whose name server answers every query with
+// NXDOMAIN, as for a name under an invalid top-level domain.
+func nxdomain(t *testing.T) *net.Resolver {
+	return fakeResolver(t, func(uint16) (byte, [][]byte) { return 3, nil })
+}
+
+// fakeResolver returns a Resolver whose name server answers each query with
+// the response code and records answer returns for the query type, so that
+// the result does not depend on the network running the test.
+func fakeResolver(t *testing.T, answer func(qtype uint16) (rcode byte, rdata [][]byte)) *net.Resolver {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			// the question ends after the name's labels, its type and class
+			end := 12
+			for end < n && buf[end] != 0 {
+				end += int(buf[end]) + 1
+			}
+			end += 5
+			if n < 12 || end > n {
+				continue
+			}
+			qtype := uint16(buf[end-4])<<8 | uint16(buf[end-3])
+			rcode, rdata := answer(qtype)
+			resp := append([]byte(nil), buf[:end]...)
+			resp[2], resp[3] = 0x85, 0x80|rcode // response, authoritative, RD, RA
+			resp[4], resp[5] = 0, 1             // one question
+			resp[6], resp[7] = 0, byte(len(rdata))
+			for i := 8; i < 12; i++ {
+				resp[i] = 0 // no authority or additional records
+			}
+			for _, rd := range rdata {
+				// a pointer to the question's name, its type and class, a
+				// TTL of 60s and the data
+				resp = append(resp, 0xc0, 12, byte(qtype>>8), byte(qtype), 0, 1, 0, 0, 0, 60, 0, byte(len(rd)))
+				resp = append(resp, rd...)
+			}
+			pc.WriteTo(resp, addr)
+		}
+	}()
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", pc.LocalAddr().String())
+		},
+	}
+}
+
+func TestHostNotFound(t *testing.T) {
+	var called []error
+	c := &Checker{
+		Hostport:             "no-such-host.invalid:80",
+		Resolver:             nxdomain(t),
+		ResolveTimeout:       time.Second,
+		SkipInterfaceCheck:   true,
+		HostNotFoundInterval: time.Hour,
+		OnHostNotFound:       func(err error) { called = append(called, err) },
+	}
+	for i := 0; i < 3; i++ {
+		st := c.Step()
+		if st.State != Down || !errors.Is(st.Err, ErrHostNotFound) || !errors.Is(st.Err, ErrDNS) || st.DownCause != CauseDNS {
+			t.Fatalf("check %d: %v with %v (cause %v), want down with ErrHostNotFound", i, st.State, st.Err, st.DownCause)
+		}
+	}
+	if len(called) != 1 || !errors.Is(called[0], ErrHostNotFound) {
+		t.Errorf("OnHostNotFound called with %v, want once", called)
+	}
+	if got := Reason(c.Status().Err, 0); got != "no such host" {
+		t.Errorf("Reason = %q", got)
+	}
+	if d := c.nextInterval(); d != time.Hour {
+		t.Errorf("next check in %v, want HostNotFoundInterval", d)
+	}
+}
+
+func TestGiveUpOnHostNotFound(t *testing.T) {
+	gaveUp := make(chan error, 1)
+	c := &Checker{
+		Hostport:             "no-such-host.invalid:80",
+		Resolver:             nxdomain(t),
+		ResolveTimeout:       time.Second,
+		SkipInterfaceCheck:   true,
+		GiveUpOnHostNotFound: true,
+		OnGiveUp:             func(reason error) { gaveUp <- reason },
+	}
+	c.Start()
+	select {
+	case err := <-gaveUp:
+		if !errors.Is(err, ErrHostNotFound) {
+			t.Errorf("gave up with %v, want ErrHostNotFound", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not give up")
+	}
+	within(t, 5*time.Second, "StopAndWait", c.StopAndWait)
+	if c.isRunning() {
+		t.Error("still running after giving up")
+	}
+}
+
+func TestNoRoute(t *testing.T) {
+	for _, errno := range []syscall.Errno{syscall.EHOSTUNREACH, syscall.ENETUNREACH} {
+		opErr := &net.OpError{Op: "dial", Net: "tcp", Err: errno}
+		probes := map[string]*Checker{
+			"ping": pinged(failing(opErr)),
+			"dialer": {Hostport: "192.0.2.1:80", SkipInterfaceCheck: true, Dialer: dialFunc(func(context.Context, string, string) (net.Conn, error) {
+				return nil, opErr
+			})},
+		}
+		for name, c := range probes {
+			st := c.Step()
+			if !errors.Is(st.Err, ErrNoRoute) || errors.Is(st.Err, ErrTimeout) || !errors.Is(st.Err, errno) {
+				t.Errorf("%s %v: %v, want ErrNoRoute wrapping it", name, errno, st.Err)
+			}
+			if got := Reason(st.Err, 0); got != "no route to host" {
+				t.Errorf("%s %v: Reason = %q", name, errno, got)
+			}
+		}
+	}
+	if err := classify(context.DeadlineExceeded); errors.Is(err, ErrNoRoute) || !errors.Is(err, ErrTimeout) {
+		t.Errorf("a timeout classified as %v", err)
+	}
+}
+
+func TestNoRouteImmediate(t *testing.T) {
+	for _, immediate := range []bool{false, true} {
+		var err error
+		c := pinged(func(context.Context) error { return err })
+		c.StickyDuration = time.Hour
+		c.NoRouteImmediate = immediate
+		c.Step()
+		err = &net.OpError{Op: "dial", Err: syscall.EHOSTUNREACH}
+		if st := c.Step(); st.State == Down != immediate {
+			t.Errorf("NoRouteImmediate %v: %v within StickyDuration", immediate, st.State)
+		}
+		err = errors.New("other")
+		c.Step()
+		if st := c.Status(); immediate && st.State != Down || !immediate && st.State != Up {
+			t.Errorf("NoRouteImmediate %v: %v after another failure", immediate, st.State)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package reachable
+
+import "time"
+
+// eventBuffer is how many undelivered Events a channel returned by Events
+// holds before the oldest is dropped.
+const eventBuffer = 64
+
+// EventKind identifies what an Event reports.
+type EventKind int
+
+const (
+	// EventProbe reports a check whose probe succeeded.
+	EventProbe EventKind = iota
+
+	// EventError reports a check whose probe failed, with its error.
+	EventError
+
+	// EventTransition reports a change of State, after the EventProbe or
+	// EventError of the check that caused it.
+	EventTransition
+
+	// EventHeartbeat reports a polling interval that passed without a
+	// probe, because of Enabled, ShouldCheck, SkipProbesInWindows,
+	// MaxBytesPerHour or MaxConcurrentChecks, with the State held.
+	EventHeartbeat
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventProbe:
+		return "probe"
+	case EventError:
+		return "error"
+	case EventTransition:
+		return "transition"
+	case EventHeartbeat:
+		return "heartbeat"
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding an EventKind as its
+// String form.
+func (k EventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Event is a single entry in the stream returned by Checker.Events. Kind
+// says which of the other fields are meaningful.
+type Event struct {
+	Kind EventKind `json:"kind"`
+
+	// Time is when the event happened, from Checker.Clock.
+	Time time.Time `json:"time"`
+
+	// State is the Checker's State after the event. From is the State
+	// before an EventTransition, and Unknown for other kinds.
+	State State `json:"state"`
+	From  State `json:"from,omitempty"`
+
+	// Host is the hostport probed, and Latency how long the probe took, for
+	// EventProbe and EventError.
+	Host    string        `json:"host,omitempty"`
+	Latency time.Duration `json:"latency,omitempty"`
+
+	// Err is the probe's error for EventError, and for an EventProbe that
+	// found the service down with Checker.RefusedIsReachable. Error holds the
+	// same error as a string.
+	Err   error  `json:"-"`
+	Error string `json:"error,omitempty"`
+}
+
+// Events returns a channel of every check and State change, as a single
+// alternative to the separate callbacks. Each polling cycle sends one
+// EventProbe, EventError or EventHeartbeat, followed by an EventTransition
+// if the State changed, except that no EventTransition is sent within
+// SuppressWindows.
+//
+// Events are sent without ever blocking the Checker, into a buffer of 64.
+// If the receiver falls behind the oldest buffered event is dropped, so
+// events may be missed but those received are in order and the latest is
+// always delivered. When the Checker stops the channel is closed after any
+// buffered events. Each call returns a new channel that receives every
+// event until then. If the Checker is not running the channel is returned
+// closed.
+func (c *Checker) Events() <-chan Event {
+	ch := make(chan Event, eventBuffer)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		close(ch)
+		return ch
+	}
+	c.events = append(c.events, ch)
+	return ch
+}
+
+// emit sends e to every Events channel without blocking.
+func (c *Checker) emit(e Event) {
+	e.Time = c.clock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e.Err != nil {
+		e.Error = e.Err.Error()
+	}
+	for _, ch := range c.events {
+		for sent := false; !sent; {
+			select {
+			case ch <- e:
+				sent = true
+			default:
+				// full: drop the oldest event to make room
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// heartbeat emits an EventHeartbeat with the current State.
+func (c *Checker) heartbeat() {
+	c.mu.Lock()
+	state := c.status.State
+	c.mu.Unlock()
+	c.emit(Event{Kind: EventHeartbeat, State: state})
+}
+
+// closeEvents closes every Events channel.
+func (c *Checker) closeEvents() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range c.events {
+		close(ch)
+	}
+	c.events = nil
+}
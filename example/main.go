@@ -15,7 +15,7 @@ func main() {
 	ival := flag.Duration("i", time.Second*5, "interval for reachability checks")
 	flag.Parse()
 
-	reachable.DefaultInterval = *ival
+	reachable.SetDefaultInterval(*ival)
 
 	// example usage for 2 domains and separate instances
 	os.Stdout.Write([]byte("Toggle network a few times to see notifications:\n"))
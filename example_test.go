@@ -0,0 +1,43 @@
+package reachable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pbnjay/reachable"
+)
+
+// A scripted sequence of probe outcomes, one per minute, shows which
+// notifications fire when StickyDuration holds the host up through failures
+// for 90 seconds after its last success: the lone failures at 1m and 4m are
+// absorbed, but the second failure in a row at 2m is reported.
+func ExampleChecker_Step() {
+	down := errors.New("down")
+	outcomes := []error{nil, down, down, nil, down, nil, nil}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	start := now
+
+	c := &reachable.Checker{
+		SkipInterfaceCheck: true,
+		StickyDuration:     90 * time.Second,
+		PingFunc: func(context.Context) error {
+			err := outcomes[0]
+			outcomes = outcomes[1:]
+			return err
+		},
+		Clock: func() time.Time { return now },
+		Notifier: func(r bool) {
+			fmt.Printf("%v reachable=%v\n", now.Sub(start), r)
+		},
+	}
+	for len(outcomes) > 0 {
+		c.Step()
+		now = now.Add(time.Minute)
+	}
+	// Output:
+	// 0s reachable=true
+	// 2m0s reachable=false
+	// 3m0s reachable=true
+}
@@ -0,0 +1,51 @@
+package reachable
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// StateVar is an expvar.Var reporting a Checker's state. Publishing one
+// with expvar.Publish makes the state appear in /debug/vars:
+//
+//    expvar.Publish("reachable", reachable.DefaultVar())
+//    expvar.Publish("db", dbChecker.Var())
+//
+// Its value is read afresh from Status each time the variable is read:
+//
+//    {"name": "db:5432", "state": "up", "reachable": true, "lastChange": ..., "lastCheck": ...}
+//
+// The package itself does not import expvar, which registers /debug/vars on
+// http.DefaultServeMux, so nothing is published unless the app does so.
+type StateVar struct {
+	c *Checker
+}
+
+// Var returns an expvar.Var for c.
+func (c *Checker) Var() StateVar {
+	return StateVar{c}
+}
+
+// DefaultVar returns an expvar.Var for the default Checker used by Start and
+// NetworkIsReachable.
+func DefaultVar() StateVar {
+	return StateVar{singleton}
+}
+
+// String returns the JSON encoding of the Checker's state, implementing
+// expvar.Var.
+func (v StateVar) String() string {
+	st := v.c.Status()
+	data, err := json.Marshal(struct {
+		Name       string            `json:"name"`
+		Tags       map[string]string `json:"tags,omitempty"`
+		State      State             `json:"state"`
+		Reachable  bool              `json:"reachable"`
+		LastChange time.Time         `json:"lastChange"`
+		LastCheck  time.Time         `json:"lastCheck"`
+	}{st.Name, st.Tags, st.State, st.Reachable, st.LastChange, st.LastCheck})
+	if err != nil {
+		return "null"
+	}
+	return string(data)
+}
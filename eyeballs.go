@@ -0,0 +1,126 @@
+package reachable
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// HappyEyeballsDelay is the head start IPv6 is given over IPv4 in a
+// Checker.HappyEyeballs race, the Connection Attempt Delay of RFC 8305.
+var HappyEyeballsDelay = 250 * time.Millisecond
+
+// attempt is the outcome of dialing one address family.
+type attempt struct {
+	network string
+	target  string
+	conn    net.Conn
+	err     error
+}
+
+// probeEyeballs resolves the host in hostport and races its IPv6 and IPv4
+// addresses in the manner of RFC 8305, starting IPv4 after
+// HappyEyeballsDelay or once IPv6 has failed. The first family to connect
+// wins and the other attempt is cancelled.
+func (c *Checker) probeEyeballs(ctx context.Context, hostport string, res *result) error {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	rctx, cancel := context.WithTimeout(ctx, c.resolveTimeout(c.timeout()))
+	ips, err := c.resolver().LookupIPAddr(rctx, host)
+	cancel()
+	res.dnsTime = time.Since(start)
+	if err != nil {
+		return err
+	}
+	families := map[string][]string{}
+	for _, ip := range ips {
+		network := "tcp6"
+		if ip.IP.To4() != nil {
+			network = "tcp4"
+		}
+		families[network] = append(families[network], net.JoinHostPort(ip.String(), port))
+		res.resolved = append(res.resolved, ip.String())
+	}
+	if len(families) == 0 {
+		return &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	if err := c.checkResolved(host, res.resolved); err != nil {
+		return err
+	}
+
+	start = time.Now()
+	dctx, cancel := context.WithTimeout(ctx, c.connectTimeout(c.timeout()))
+	defer cancel()
+	results := make(chan attempt, len(families))
+	_, dual := families["tcp6"]
+	delay := HappyEyeballsDelay
+	v6failed := make(chan struct{})
+	for network, addrs := range families {
+		go func(network string, addrs []string) {
+			if network == "tcp4" && dual {
+				// IPv6 gets a head start
+				t := time.NewTimer(delay)
+				select {
+				case <-t.C:
+				case <-v6failed:
+				case <-dctx.Done():
+				}
+				t.Stop()
+				if dctx.Err() != nil {
+					results <- attempt{network: network, err: dctx.Err()}
+					return
+				}
+			}
+			a := c.dialFamily(dctx, res.source, res.dials, network, addrs)
+			if network == "tcp6" && a.err != nil {
+				close(v6failed)
+			}
+			results <- a
+		}(network, addrs)
+	}
+
+	var first attempt
+	for i := 0; i < len(families); i++ {
+		a := <-results
+		if a.err != nil {
+			if i == 0 {
+				first = a
+			}
+			continue
+		}
+		cancel()
+		pending := len(families) - i - 1
+		go func() {
+			// close the losing connection, should it have won a close race
+			for ; pending > 0; pending-- {
+				if l := <-results; l.conn != nil {
+					l.conn.Close()
+				}
+			}
+		}()
+		res.connectTime = time.Since(start)
+		res.target = a.target
+		res.family = a.network
+		return c.finish(ctx, a.conn, res)
+	}
+	res.connectTime = time.Since(start)
+	res.target = first.target
+	return first.err
+}
+
+// dialFamily tries addrs in turn over network, from source if set, until one
+// connects, recording the attempts in dials.
+func (c *Checker) dialFamily(ctx context.Context, source net.IP, dials *dialLog, network string, addrs []string) attempt {
+	a := attempt{network: network}
+	for _, addr := range addrs {
+		a.target = addr
+		a.conn, a.err = c.dial(ctx, &result{source: source, dials: dials}, net.Dialer{}, network, addr)
+		if a.err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	return a
+}
@@ -0,0 +1,84 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dualStack returns a Resolver answering every name with 127.0.0.1 and ::1.
+func dualStack(t *testing.T) *net.Resolver {
+	return fakeResolver(t, func(qtype uint16) (byte, [][]byte) {
+		switch qtype {
+		case 1: // A
+			return 0, [][]byte{net.IPv4(127, 0, 0, 1).To4()}
+		case 28: // AAAA
+			return 0, [][]byte{net.IPv6loopback}
+		}
+		return 0, nil
+	})
+}
+
+func TestHappyEyeballsHeadStart(t *testing.T) {
+	defer func(d time.Duration) { HappyEyeballsDelay = d }(HappyEyeballsDelay)
+	HappyEyeballsDelay = 100 * time.Millisecond
+
+	tests := []struct {
+		name   string
+		v6     func(ctx context.Context) error // the outcome of the IPv6 dial
+		family string                          // the family that should win
+		v4     bool                            // whether IPv4 should be dialed
+		after  time.Duration                   // the least delay before IPv4, if dialed
+	}{
+		{"IPv6 connects", func(context.Context) error { return nil }, "tcp6", false, 0},
+		{"IPv6 hangs", hang, "tcp4", true, HappyEyeballsDelay},
+		{"IPv6 fails", func(context.Context) error { return errors.New("unreachable") }, "tcp4", true, 0},
+	}
+	for _, tt := range tests {
+		var mu sync.Mutex
+		dialed := map[string]time.Time{}
+		c := &Checker{
+			Hostport:           "dual.test:80",
+			HappyEyeballs:      true,
+			Resolver:           dualStack(t),
+			SkipInterfaceCheck: true,
+			Timeout:            5 * time.Second,
+			Dialer: dialFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+				mu.Lock()
+				dialed[network] = time.Now()
+				mu.Unlock()
+				if network == "tcp6" {
+					if err := tt.v6(ctx); err != nil {
+						return nil, err
+					}
+				}
+				a, b := net.Pipe()
+				b.Close()
+				return a, nil
+			}),
+		}
+		st := c.Step()
+		if st.State != Up || st.Family != tt.family {
+			t.Errorf("%s: %v via %q (%v), want up via %s", tt.name, st.State, st.Family, st.Err, tt.family)
+		}
+		mu.Lock()
+		v6, v4 := dialed["tcp6"], dialed["tcp4"]
+		mu.Unlock()
+		if !tt.v4 {
+			if !v4.IsZero() {
+				t.Errorf("%s: IPv4 was dialed although IPv6 connected", tt.name)
+			}
+			continue
+		}
+		if v4.IsZero() {
+			t.Errorf("%s: IPv4 was not dialed", tt.name)
+			continue
+		}
+		if gap := v4.Sub(v6); gap < tt.after || gap > tt.after+HappyEyeballsDelay/2 {
+			t.Errorf("%s: IPv4 dialed %v after IPv6, want about %v", tt.name, gap, tt.after)
+		}
+	}
+}
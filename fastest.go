@@ -0,0 +1,54 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FastestReachable probes every entry of hostports concurrently, in the same
+// way as a Checker with that Hostport and no interface check, and returns the
+// first to succeed, with its probe latency. The remaining probes are
+// cancelled as soon as there is a winner. This is useful for choosing the
+// nearest mirror or region. If ctx has no deadline, the probes time out after
+// DefaultTimeout. If no host is reachable the error is the first host's
+// failure, matching the same sentinel errors as Status.Error would.
+func FastestReachable(ctx context.Context, hostports []string) (string, time.Duration, error) {
+	if len(hostports) == 0 {
+		return "", 0, &classError{ErrConfig, errors.New("reachable: no hosts to probe")}
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout())
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		i       int
+		latency time.Duration
+		err     error
+	}
+	done := make(chan outcome, len(hostports))
+	for i, hp := range hostports {
+		go func(i int, hp string) {
+			c := &Checker{Hostport: hp}
+			var res result
+			start := time.Now()
+			err := c.probeHost(ctx, hp, &res)
+			done <- outcome{i, time.Since(start), err}
+		}(i, hp)
+	}
+
+	errs := make([]error, len(hostports))
+	for range hostports {
+		o := <-done
+		if o.err == nil {
+			return hostports[o.i], o.latency, nil
+		}
+		errs[o.i] = o.err
+	}
+	return "", 0, classify(fmt.Errorf("reachable: none of %d hosts reachable, %s: %w", len(hostports), hostports[0], errs[0]))
+}
@@ -0,0 +1,44 @@
+package reachable
+
+import "time"
+
+// DefaultFlapHistory is how many recent State transitions are kept for
+// FlapCount and History when Checker.FlapHistory is unset.
+const DefaultFlapHistory = 64
+
+// FlapCount returns how many State transitions happened within the last
+// window, as a measure of how unstable the host has recently been. At most the
+// last FlapHistory transitions since Start are kept, so that is the largest
+// count returned. The initial transition from Unknown is not counted.
+func (c *Checker) FlapCount(window time.Duration) int {
+	since := c.clock().Add(-window)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.transitions {
+		if t.From != Unknown && t.At.After(since) {
+			n++
+		}
+	}
+	return n
+}
+
+// addTransition records a State transition. c.mu must be held.
+func (c *Checker) addTransition(t Transition) {
+	n := c.FlapHistory
+	if n == 0 {
+		n = DefaultFlapHistory
+	}
+	if n < 0 {
+		return
+	}
+	if c.transitions == nil {
+		// allocated once at its final size, so history never grows past it
+		c.transitions = make([]Transition, 0, n)
+	}
+	if len(c.transitions) >= n {
+		copy(c.transitions, c.transitions[len(c.transitions)-n+1:])
+		c.transitions = c.transitions[:n-1]
+	}
+	c.transitions = append(c.transitions, t)
+}
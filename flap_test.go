@@ -0,0 +1,77 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// flapping returns a Checker whose probes alternately succeed and fail, on a
+// fake clock advancing a second per check.
+func flapping() *Checker {
+	now := time.Now()
+	n := 0
+	c := pinged(func(context.Context) error {
+		n++
+		if n%2 == 0 {
+			return errors.New("down")
+		}
+		return nil
+	})
+	c.Clock = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return c
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+func TestBuffersBounded(t *testing.T) {
+	c := flapping()
+	c.FlapHistory = 16
+	c.ConfidenceWindow = 8
+	c.TrendWindow = 8
+	for i := 0; i < 1000; i++ {
+		c.Step()
+	}
+	before := heapInUse()
+	for i := 0; i < 20000; i++ {
+		c.Step()
+	}
+	after := heapInUse()
+
+	if h := c.History(); len(h) != 16 {
+		t.Errorf("%d transitions kept, want FlapHistory 16", len(h))
+	}
+	c.mu.Lock()
+	transitions, recent, latencies := cap(c.transitions), cap(c.recent), cap(c.latencies)
+	c.mu.Unlock()
+	if transitions != 16 || recent != 8 || latencies > 8 {
+		t.Errorf("buffer capacities %d, %d, %d, want 16, 8 and at most 8", transitions, recent, latencies)
+	}
+	if after > before && after-before > 1<<20 {
+		t.Errorf("heap grew by %d bytes over 20000 flapping checks", after-before)
+	}
+	if n := c.FlapCount(time.Hour); n != 16 {
+		t.Errorf("FlapCount = %d, want the 16 kept", n)
+	}
+}
+
+func TestFlapHistoryNegativeKeepsNone(t *testing.T) {
+	c := flapping()
+	c.FlapHistory = -1
+	for i := 0; i < 10; i++ {
+		c.Step()
+	}
+	if h := c.History(); len(h) != 0 {
+		t.Errorf("%d transitions kept with a negative FlapHistory", len(h))
+	}
+}
@@ -0,0 +1,34 @@
+package reachable
+
+import (
+	"errors"
+	"fmt"
+)
+
+// checkGiveUp stops the Checker if res meets one of the conditions for
+// giving up, and then calls OnGiveUp. It runs on the polling goroutine, which
+// stop leaves to finish the cycle, so StopAndWait also waits for OnGiveUp.
+func (c *Checker) checkGiveUp(res result) {
+	if res.ok || c.gaveUp {
+		return
+	}
+	var reason error
+	if c.GiveUpOnHostNotFound && errors.Is(res.err, ErrHostNotFound) {
+		reason = res.err
+	} else if c.MaxFailures > 0 {
+		if st := c.Status(); st.State == Down && st.ConsecutiveCount >= c.MaxFailures {
+			reason = fmt.Errorf("reachable: giving up after %d consecutive failures: %w", st.ConsecutiveCount, res.err)
+		}
+	}
+	if reason == nil {
+		return
+	}
+	c.gaveUp = true
+	if c.isRunning() && !c.stop() {
+		// already stopped by Stop, which is not giving up
+		return
+	}
+	if c.OnGiveUp != nil {
+		c.dispatch(func() { c.OnGiveUp(reason) })
+	}
+}
@@ -0,0 +1,49 @@
+package reachable
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGiveUpStep(t *testing.T) {
+	var calls int32
+	c := pinged(failing(errors.New("down")))
+	c.MaxFailures = 3
+	c.OnGiveUp = func(error) { atomic.AddInt32(&calls, 1) }
+	for i := 1; i <= 5; i++ {
+		c.Step()
+		want := int32(0)
+		if i >= 3 {
+			want = 1
+		}
+		if n := atomic.LoadInt32(&calls); n != want {
+			t.Errorf("after check %d OnGiveUp called %d times, want %d", i, n, want)
+		}
+	}
+}
+
+func TestStopAndWaitWaitsForGiveUp(t *testing.T) {
+	for _, pool := range []*Pool{nil, NewPool(1)} {
+		var done int32
+		called := make(chan struct{})
+		c := pinged(failing(errors.New("down")))
+		c.Pool = pool
+		c.MaxFailures = 1
+		c.OnGiveUp = func(error) {
+			close(called)
+			time.Sleep(50 * time.Millisecond)
+			atomic.StoreInt32(&done, 1)
+		}
+		c.Start()
+		<-called
+		within(t, 5*time.Second, "StopAndWait", c.StopAndWait)
+		if atomic.LoadInt32(&done) == 0 {
+			t.Errorf("pool %v: StopAndWait returned while OnGiveUp was running", pool != nil)
+		}
+		if c.isRunning() {
+			t.Errorf("pool %v: still running after giving up", pool != nil)
+		}
+	}
+}
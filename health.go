@@ -0,0 +1,85 @@
+package reachable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxHealthBody is how much of a health endpoint's response is read.
+const maxHealthBody = 64 << 10
+
+// Health is the response of a health endpoint probed with Checker.HealthJSON,
+// in the "application/health+json" format of the IETF health check draft
+// (draft-inadarei-api-health-check).
+type Health struct {
+	// Status is "pass", "warn" or "fail", or one of their aliases "ok" and
+	// "up" for pass, and "error" and "down" for fail.
+	Status string `json:"status"`
+
+	Version   string   `json:"version,omitempty"`
+	ReleaseID string   `json:"releaseId,omitempty"`
+	Notes     []string `json:"notes,omitempty"`
+	Output    string   `json:"output,omitempty"`
+	ServiceID string   `json:"serviceId,omitempty"`
+
+	// Checks holds the detailed status of the service's components, keyed
+	// by "component:measurement" name.
+	Checks map[string][]HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck is the status of one component in Health.Checks.
+type HealthCheck struct {
+	ComponentID   string      `json:"componentId,omitempty"`
+	ComponentType string      `json:"componentType,omitempty"`
+	ObservedValue interface{} `json:"observedValue,omitempty"`
+	ObservedUnit  string      `json:"observedUnit,omitempty"`
+	Status        string      `json:"status,omitempty"`
+	Time          string      `json:"time,omitempty"`
+	Output        string      `json:"output,omitempty"`
+}
+
+// probeHealth GETs u and judges the host by the health JSON it returns. A
+// response without one is judged by its status code like any HTTP probe.
+func (c *Checker) probeHealth(ctx context.Context, u *url.URL, res *result) error {
+	res.target = c.dialAddr(urlHostport(u))
+	res.health, res.healthWarn = nil, nil
+	status, body, err := c.httpRequest(ctx, http.MethodGet, u, res, true, maxHealthBody)
+	if err != nil {
+		return err
+	}
+	var h Health
+	err = json.Unmarshal(body, &h)
+	if err == nil && h.Status == "" {
+		err = fmt.Errorf("no status")
+	}
+	if err != nil {
+		if status >= 400 {
+			return &classError{ErrHTTPStatus, fmt.Errorf("reachable: GET %s returned %d %s",
+				u.Redacted(), status, http.StatusText(status))}
+		}
+		return &classError{ErrHealthResponse, fmt.Errorf("reachable: GET %s returned malformed health JSON: %v", u.Redacted(), err)}
+	}
+	res.health = &h
+	switch strings.ToLower(h.Status) {
+	case "pass", "ok", "up":
+		return nil
+	case "warn":
+		res.healthWarn = &classError{ErrHealthWarn, fmt.Errorf("reachable: %s health is warn%s", u.Redacted(), healthOutput(h))}
+		return nil
+	case "fail", "error", "down":
+		return &classError{ErrHealthFail, fmt.Errorf("reachable: %s health is %s%s", u.Redacted(), h.Status, healthOutput(h))}
+	}
+	return &classError{ErrHealthResponse, fmt.Errorf("reachable: %s reports unknown health status %q", u.Redacted(), h.Status)}
+}
+
+// healthOutput formats h.Output for an error message.
+func healthOutput(h Health) string {
+	if h.Output == "" {
+		return ""
+	}
+	return ": " + h.Output
+}
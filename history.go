@@ -0,0 +1,77 @@
+package reachable
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Transition is a State change in a Checker's history.
+type Transition struct {
+	// At is when the check that caused the change completed.
+	At time.Time `json:"at"`
+
+	From State `json:"from"`
+	To   State `json:"to"`
+
+	// Latency is the duration of the probe, and Err the text of its error,
+	// if it failed.
+	Latency time.Duration `json:"latency"`
+	Err     string        `json:"error,omitempty"`
+}
+
+// History returns the most recent State transitions since Start, oldest
+// first, including the first one from Unknown. At most FlapHistory are kept.
+// It is safe to call while the Checker is running.
+func (c *Checker) History() []Transition {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Transition(nil), c.transitions...)
+}
+
+// Format is an output format for WriteHistory.
+type Format int
+
+const (
+	// CSV writes a header row and then one row per transition, with the
+	// columns at (RFC 3339), from, to, latency_ms and error.
+	CSV Format = iota
+
+	// JSONLines writes each transition as a JSON object on its own line.
+	JSONLines
+)
+
+// WriteHistory writes the transitions returned by History to w, for offline
+// analysis, in the given format. It is safe to call while the Checker is
+// running.
+func (c *Checker) WriteHistory(w io.Writer, format Format) error {
+	history := c.History()
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"at", "from", "to", "latency_ms", "error"})
+		for _, t := range history {
+			cw.Write([]string{
+				t.At.Format(time.RFC3339Nano),
+				t.From.String(),
+				t.To.String(),
+				strconv.FormatFloat(float64(t.Latency)/float64(time.Millisecond), 'f', 3, 64),
+				t.Err,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	case JSONLines:
+		enc := json.NewEncoder(w)
+		for _, t := range history {
+			if err := enc.Encode(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("reachable: unknown history format %d", format)
+}
@@ -0,0 +1,56 @@
+package reachable
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// name returns the Checker's Name, defaulting to its hosts.
+func (c *Checker) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return strings.Join(c.hostports(), ",")
+}
+
+// hostports returns the list of hosts to probe.
+func (c *Checker) hostports() []string {
+	if len(c.Hostports) > 0 {
+		return c.Hostports
+	}
+	return []string{c.Hostport}
+}
+
+// DefaultPort is the port assumed for a Hostport without one.
+const DefaultPort = "80"
+
+// withDefaultPort appends DefaultPort to hostport if it has no port. IPv6
+// addresses may be given bare ("::1") or bracketed ("[::1]").
+func withDefaultPort(hostport string) string {
+	if _, _, err := net.SplitHostPort(hostport); err == nil {
+		return hostport
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]")
+	if strings.Count(host, ":") == 1 {
+		// not an IPv6 address but some other malformed hostport, which is
+		// left for the dial to report
+		return hostport
+	}
+	return net.JoinHostPort(host, DefaultPort)
+}
+
+// probeRotated probes one random entry of hosts, and with RotateFailover a
+// second one if the first fails.
+func (c *Checker) probeRotated(ctx context.Context, hosts []string, res *result) error {
+	i := c.rand().Intn(len(hosts))
+	err := c.probeHost(ctx, hosts[i], res)
+	if err == nil || !c.RotateFailover || ctx.Err() != nil {
+		return err
+	}
+	j := c.rand().Intn(len(hosts) - 1)
+	if j >= i {
+		j++
+	}
+	return c.probeHost(ctx, hosts[j], res)
+}
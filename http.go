@@ -0,0 +1,74 @@
+package reachable
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ReadinessHandler returns an HTTP handler suitable for a Kubernetes readiness
+// probe. It responds 200 OK while c considers its host reachable (Up or
+// Degraded) and 503 Service Unavailable otherwise, including before the first
+// check completes. The body is the JSON encoding of c.Status(). Responses are
+// served from the last completed check, so the handler never blocks on the
+// network, and are marked uncacheable.
+//
+//    http.Handle("/readyz", reachable.ReadinessHandler(&c))
+//
+func ReadinessHandler(c *Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		st := c.Status()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if st.State.reachable() {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(st)
+	}
+}
+
+// Transport is an http.RoundTripper that fails fast while its Checker finds
+// the host unreachable, rather than letting each request wait for a dial
+// timeout. See Checker.RoundTripper.
+type Transport struct {
+	// Checker is consulted before each request.
+	Checker *Checker
+
+	// Next handles the requests that are let through. If nil,
+	// http.DefaultTransport is used.
+	Next http.RoundTripper
+
+	// Disabled turns fail-fast off, so every request is passed to Next.
+	Disabled bool
+}
+
+// RoundTripper wraps next, which may be nil for http.DefaultTransport, so that
+// requests fail immediately with an error matching ErrNetworkUnreachable while
+// c reports its host Down or behind a captive portal. Requests go through as
+// usual before the first check completes.
+//
+//    client := &http.Client{Transport: c.RoundTripper(nil)}
+//
+func (c *Checker) RoundTripper(next http.RoundTripper) *Transport {
+	return &Transport{Checker: c, Next: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.Disabled {
+		if st := t.Checker.Status(); st.State != Unknown && !st.State.reachable() {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, &classError{ErrNetworkUnreachable, fmt.Errorf("reachable: %s is %s, not sending %s %s",
+				st.Name, st.State, req.Method, req.URL.Redacted())}
+		}
+	}
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
@@ -0,0 +1,192 @@
+package reachable
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// globalRand is shared by all Checkers without their own Rand.
+var globalRand = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())})
+
+// lockedSource makes a rand.Source safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	s.src.Seed(seed)
+	s.mu.Unlock()
+}
+
+func (c *Checker) rand() *rand.Rand {
+	if c.Rand != nil {
+		return c.Rand
+	}
+	return globalRand
+}
+
+// DefaultFastStartInterval is the interval between fast-start checks when
+// Checker.FastStartInterval is unset.
+const DefaultFastStartInterval = time.Second
+
+// JitterStrategy selects how polling intervals are randomized. See
+// Checker.JitterStrategy.
+type JitterStrategy int
+
+const (
+	// JitterAdditive adds a random delay of up to Checker.Jitter, so no
+	// randomization at all when Jitter is zero.
+	JitterAdditive JitterStrategy = iota
+
+	// JitterEqual waits half the interval plus a random delay of up to the
+	// other half.
+	JitterEqual
+
+	// JitterFull waits a random delay of up to the whole interval.
+	JitterFull
+
+	// JitterDecorrelated waits, while the host is Down, a random delay
+	// between Interval and three times the previous delay, capped at
+	// MaxInterval, in place of the exponential backoff. Otherwise it
+	// behaves like JitterAdditive.
+	JitterDecorrelated
+)
+
+// NextCheck returns when the next check is scheduled, or the zero Time if
+// the Checker is not running. A CheckNow call or a check that finds all
+// MaxConcurrentChecks slots taken does not change it.
+func (c *Checker) NextCheck() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return time.Time{}
+	}
+	return c.nextCheck
+}
+
+// nextInterval returns the delay before the next check, no shorter than
+// MinInterval, and records when that is for NextCheck.
+func (c *Checker) nextInterval() time.Duration {
+	d := c.scheduledInterval()
+	if d < MinInterval {
+		d = MinInterval
+	}
+	c.mu.Lock()
+	c.nextCheck = time.Now().Add(d)
+	c.mu.Unlock()
+	return d
+}
+
+// firstInterval returns the delay before the first check after Start, which
+// is none so that the initial state is known at once, unless AlignToClock
+// schedules it, and records when that is for NextCheck.
+func (c *Checker) firstInterval() time.Duration {
+	if c.AlignToClock {
+		return c.nextInterval()
+	}
+	c.mu.Lock()
+	c.nextCheck = time.Now()
+	c.mu.Unlock()
+	return 0
+}
+
+// scheduledInterval computes the delay before the next check. A pending
+// ConfirmUpAfter confirmation comes first, then ShouldCheckInterval, then
+// the fast-start phase, HostNotFoundInterval, NextInterval and backoff, which
+// are aligned for AlignToClock, and jitter is applied to all but the first two.
+func (c *Checker) scheduledInterval() time.Duration {
+	c.mu.Lock()
+	state := c.status.State
+	failures := c.status.ConsecutiveCount
+	checks := c.stats.Checks
+	c.mu.Unlock()
+
+	if c.confirming {
+		return c.ConfirmUpAfter
+	}
+	if c.gated && c.ShouldCheckInterval > 0 {
+		return c.ShouldCheckInterval
+	}
+	d := c.Interval
+	if checks < c.FastStartProbes {
+		d = orDefault(c.FastStartInterval, DefaultFastStartInterval)
+	} else if c.hostNotFound && c.HostNotFoundInterval > 0 {
+		d = c.HostNotFoundInterval
+	} else if c.NextInterval != nil {
+		if next := c.NextInterval(state); next > 0 {
+			d = next
+		}
+	} else if state == Down && c.JitterStrategy == JitterDecorrelated {
+		return c.decorrelated()
+	} else if state == Down {
+		d = c.backoff(failures)
+	}
+	c.lastDelay = 0
+	if c.AlignToClock {
+		d = align(time.Now(), d)
+	}
+	return c.jitter(d)
+}
+
+// align returns the delay from now until the next multiple of d, skipping
+// one too close to be distinct from now.
+func align(now time.Time, d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	wait := now.Truncate(d).Add(d).Sub(now)
+	if wait < MinInterval {
+		wait += d
+	}
+	return wait
+}
+
+// jitter randomizes d according to JitterStrategy.
+func (c *Checker) jitter(d time.Duration) time.Duration {
+	switch c.JitterStrategy {
+	case JitterEqual:
+		if half := d / 2; d-half > 0 {
+			return half + time.Duration(c.rand().Int63n(int64(d-half)))
+		}
+	case JitterFull:
+		if d > 0 {
+			return time.Duration(c.rand().Int63n(int64(d)))
+		}
+	default:
+		if c.Jitter > 0 {
+			return d + time.Duration(c.rand().Int63n(int64(c.Jitter)))
+		}
+	}
+	return d
+}
+
+// decorrelated returns the next JitterDecorrelated delay while Down.
+func (c *Checker) decorrelated() time.Duration {
+	base := c.Interval
+	limit := c.MaxInterval
+	if limit <= base {
+		limit = 3 * base
+	}
+	prev := c.lastDelay
+	if prev < base {
+		prev = base
+	}
+	d := base
+	if spread := 3*prev - base; spread > 0 {
+		d += time.Duration(c.rand().Int63n(int64(spread)))
+	}
+	if d > limit {
+		d = limit
+	}
+	c.lastDelay = d
+	return d
+}
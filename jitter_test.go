@@ -0,0 +1,66 @@
+package reachable
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use as a DebugWriter.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestTinyIntervalIsClamped(t *testing.T) {
+	for _, strategy := range []JitterStrategy{JitterAdditive, JitterEqual, JitterFull, JitterDecorrelated} {
+		var log syncBuffer
+		c := pinged(ok)
+		c.Interval = time.Nanosecond
+		c.JitterStrategy = strategy
+		c.DebugWriter = &log
+		c.begin()
+		if !strings.Contains(log.String(), "below MinInterval") {
+			t.Errorf("strategy %d: no warning for a 1ns Interval in %q", strategy, log.String())
+		}
+		for i := 0; i < 10; i++ {
+			if d := c.nextInterval(); d < MinInterval {
+				t.Errorf("strategy %d: delay %v below MinInterval %v", strategy, d, MinInterval)
+			}
+		}
+	}
+}
+
+func TestTinyIntervalAndTimeoutDoNotSpin(t *testing.T) {
+	var checks int32
+	c := pinged(func(ctx context.Context) error {
+		atomic.AddInt32(&checks, 1)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	c.Interval = time.Nanosecond
+	c.Timeout = time.Nanosecond
+	c.Start()
+	time.Sleep(5 * MinInterval)
+	c.StopAndWait()
+	// the first check is immediate, then one per MinInterval at most
+	if n := atomic.LoadInt32(&checks); n < 2 || n > 7 {
+		t.Errorf("%d checks in %v with a 1ns Interval and Timeout, want one per MinInterval", n, 5*MinInterval)
+	}
+}
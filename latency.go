@@ -0,0 +1,20 @@
+package reachable
+
+import "time"
+
+// DefaultLatencySmoothing is the weight of each new sample in the latency
+// moving average when Checker.LatencySmoothing is unset.
+var DefaultLatencySmoothing = 0.3
+
+// smoothLatency folds sample into the moving average avg. A zero avg is taken
+// to mean there are no previous samples.
+func (c *Checker) smoothLatency(avg, sample time.Duration) time.Duration {
+	if avg == 0 {
+		return sample
+	}
+	alpha := c.LatencySmoothing
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultLatencySmoothing
+	}
+	return time.Duration(alpha*float64(sample) + (1-alpha)*float64(avg))
+}
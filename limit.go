@@ -0,0 +1,33 @@
+package reachable
+
+import "sync"
+
+// MaxConcurrentChecks limits how many probes may run at once across all
+// Checkers, to avoid exhausting file descriptors on constrained systems with
+// many Checkers. A check that finds all slots taken is skipped and deferred to
+// the next interval rather than queued. Zero or negative means unlimited.
+// It must be set before any Checker is started, as it is read by every check
+// without synchronization.
+var MaxConcurrentChecks = 0
+
+var (
+	slotMu sync.Mutex
+	slots  int
+)
+
+// acquireSlot reserves a probe slot, returning false if none is free.
+func acquireSlot() bool {
+	slotMu.Lock()
+	defer slotMu.Unlock()
+	if MaxConcurrentChecks > 0 && slots >= MaxConcurrentChecks {
+		return false
+	}
+	slots++
+	return true
+}
+
+func releaseSlot() {
+	slotMu.Lock()
+	slots--
+	slotMu.Unlock()
+}
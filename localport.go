@@ -0,0 +1,59 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// dial connects to addr, using d as a template for the dialer. If res is not
+// nil, addr is recorded in it as the dial target, and the connection is made
+// from its source address if one is set. When a local port range is
+// configured, the connection is made from the next port in the range, moving
+// on to the following port while ports are already in use. A Checker.Dialer
+// replaces all of this.
+func (c *Checker) dial(ctx context.Context, res *result, d net.Dialer, network, addr string) (conn net.Conn, err error) {
+	var source net.IP
+	var dials *dialLog
+	if res != nil {
+		res.target = addr
+		source = res.source
+		dials = res.dials
+	}
+	if dials != nil {
+		start := time.Now()
+		defer func() { dials.add(network, addr, time.Since(start), err) }()
+	}
+	if c.Dialer != nil {
+		return c.Dialer.DialContext(ctx, network, addr)
+	}
+	if c.LocalPortMin <= 0 || c.LocalPortMax < c.LocalPortMin {
+		if source != nil {
+			d.LocalAddr = &net.TCPAddr{IP: source}
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+
+	for n := c.LocalPortMax - c.LocalPortMin + 1; n > 0; n-- {
+		d.LocalAddr = &net.TCPAddr{IP: source, Port: c.nextLocalPort()}
+		conn, err = d.DialContext(ctx, network, addr)
+		if err == nil || !errors.Is(err, syscall.EADDRINUSE) || ctx.Err() != nil {
+			return conn, err
+		}
+	}
+	return nil, err
+}
+
+// nextLocalPort cycles through the configured local port range.
+func (c *Checker) nextLocalPort() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.localPort < c.LocalPortMin || c.localPort >= c.LocalPortMax {
+		c.localPort = c.LocalPortMin
+	} else {
+		c.localPort++
+	}
+	return c.localPort
+}
@@ -0,0 +1,225 @@
+package reachable
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MultiChecker runs a Checker for each of a set of hostports, typically loaded
+// from a configuration file. The set can be reloaded at any time: Checkers for
+// new hostports are started, those for removed hostports are stopped, and
+// those for unchanged hostports keep running undisturbed.
+//
+//    m := &reachable.MultiChecker{
+//        Notifier: func(hostport string, r bool) {
+//            log.Println(hostport, "reachable:", r)
+//        },
+//    }
+//    if err := m.ReloadFile("/etc/myapp/hosts"); err != nil {
+//        log.Fatal(err)
+//    }
+//    defer m.Stop()
+//
+//    // on SIGHUP:
+//    m.ReloadFile("/etc/myapp/hosts")
+//
+type MultiChecker struct {
+	// Interval to poll each host. If zero or negative, uses DefaultInterval.
+	Interval time.Duration
+
+	// Notifier is called with the hostport and its new reachability whenever
+	// any of the hosts changes state.
+	Notifier func(hostport string, reachable bool)
+
+	// Thresholds are percentages of reachable hosts, e.g. 50 and 90, at
+	// which OnThreshold is called with below set when ReachablePercent
+	// falls below one of them, and clear when it rises back to or above
+	// it. They are evaluated after any host changes reachability, once
+	// every host has completed a check; the first evaluation reports every
+	// threshold the fleet is already below. OnThreshold is called from the
+	// notifying Checker's goroutine, one call at a time.
+	Thresholds  []float64
+	OnThreshold func(threshold, percent float64, below bool)
+
+	mu       sync.Mutex
+	checkers map[string]*Checker
+
+	thresholdMu sync.Mutex
+	lastPercent float64
+	evaluated   bool
+}
+
+// Reload reads hostports from r, one per line, and updates the set of running
+// Checkers to match. Blank lines and lines starting with # are ignored. If r
+// cannot be read, the running set is left unchanged.
+func (m *MultiChecker) Reload(r io.Reader) error {
+	want := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		want[line] = true
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	defer m.checkThresholds() // after unlocking
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.checkers == nil {
+		m.checkers = make(map[string]*Checker)
+	}
+	for hp, c := range m.checkers {
+		if !want[hp] {
+			c.Stop()
+			delete(m.checkers, hp)
+		}
+	}
+	for hp := range want {
+		if _, ok := m.checkers[hp]; ok {
+			continue
+		}
+		c := m.newChecker(hp)
+		m.checkers[hp] = c
+		c.Start()
+	}
+	return nil
+}
+
+// ReloadFile calls Reload with the contents of the named file.
+func (m *MultiChecker) ReloadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.Reload(f)
+}
+
+// Hostports returns the sorted list of hostports currently being checked.
+func (m *MultiChecker) Hostports() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make([]string, 0, len(m.checkers))
+	for hp := range m.checkers {
+		res = append(res, hp)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Checker returns the running Checker for hostport, or nil if there is none.
+func (m *MultiChecker) Checker(hostport string) *Checker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkers[hostport]
+}
+
+// StopAndWait stops all running Checkers concurrently and clears the set,
+// returning once every one of them has exited, so that Notifier is not
+// called again. It must not be called from Notifier.
+func (m *MultiChecker) StopAndWait() {
+	m.mu.Lock()
+	checkers := m.checkers
+	m.checkers = nil
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, c := range checkers {
+		wg.Add(1)
+		go func(c *Checker) {
+			defer wg.Done()
+			c.StopAndWait()
+		}(c)
+	}
+	wg.Wait()
+}
+
+// Stop stops all running Checkers and clears the set.
+func (m *MultiChecker) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for hp, c := range m.checkers {
+		c.Stop()
+		delete(m.checkers, hp)
+	}
+}
+
+func (m *MultiChecker) newChecker(hostport string) *Checker {
+	return &Checker{
+		Hostport: hostport,
+		Interval: m.Interval,
+		Notifier: func(r bool) {
+			if m.Notifier != nil {
+				m.Notifier(hostport, r)
+			}
+			m.checkThresholds()
+		},
+	}
+}
+
+// ReachablePercent returns the percentage, from 0 to 100, of the hosts that
+// are currently reachable, leaving out those that have not completed a check
+// yet. It is 0 when no host has.
+func (m *MultiChecker) ReachablePercent() float64 {
+	percent, _ := m.percent()
+	return percent
+}
+
+// percent returns ReachablePercent, and whether every host has a known State.
+func (m *MultiChecker) percent() (float64, bool) {
+	m.mu.Lock()
+	checkers := make([]*Checker, 0, len(m.checkers))
+	for _, c := range m.checkers {
+		checkers = append(checkers, c)
+	}
+	m.mu.Unlock()
+
+	known, up := 0, 0
+	for _, c := range checkers {
+		state := c.Status().State
+		if state == Unknown {
+			continue
+		}
+		known++
+		if state.reachable() {
+			up++
+		}
+	}
+	if known == 0 {
+		return 0, false
+	}
+	return 100 * float64(up) / float64(known), known == len(checkers)
+}
+
+// checkThresholds calls OnThreshold for every threshold that ReachablePercent
+// crossed since the last evaluation.
+func (m *MultiChecker) checkThresholds() {
+	if m.OnThreshold == nil || len(m.Thresholds) == 0 {
+		return
+	}
+	m.thresholdMu.Lock()
+	defer m.thresholdMu.Unlock()
+	percent, complete := m.percent()
+	if !complete {
+		return
+	}
+	last := m.lastPercent
+	if !m.evaluated {
+		last = 100
+	}
+	m.lastPercent, m.evaluated = percent, true
+	for _, t := range m.Thresholds {
+		if wasBelow, below := last < t, percent < t; wasBelow != below {
+			m.OnThreshold(t, percent, below)
+		}
+	}
+}
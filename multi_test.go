@@ -0,0 +1,74 @@
+package reachable
+
+import (
+	"net"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// listeners returns the addresses of n listeners, which are closed when the
+// test ends.
+func listeners(t *testing.T, n int) []string {
+	t.Helper()
+	addrs := make([]string, n)
+	for i := range addrs {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { l.Close() })
+		addrs[i] = l.Addr().String()
+	}
+	return addrs
+}
+
+func TestMultiCheckerStopAndWait(t *testing.T) {
+	hosts := listeners(t, 50)
+	base := runtime.NumGoroutine()
+
+	var mu sync.Mutex
+	var notified int
+	stopped := false
+	m := &MultiChecker{
+		Notifier: func(hostport string, r bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			if stopped {
+				t.Errorf("%s notified after StopAndWait", hostport)
+			}
+			notified++
+		},
+	}
+	for cycle := 0; cycle < 3; cycle++ {
+		mu.Lock()
+		notified, stopped = 0, false
+		mu.Unlock()
+		if err := m.Reload(strings.NewReader(strings.Join(hosts, "\n"))); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			mu.Lock()
+			n := notified
+			mu.Unlock()
+			if n == len(hosts) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("cycle %d: %d of %d hosts notified", cycle, n, len(hosts))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		within(t, 5*time.Second, "StopAndWait", m.StopAndWait)
+		mu.Lock()
+		stopped = true
+		mu.Unlock()
+		if hp := m.Hostports(); len(hp) != 0 {
+			t.Errorf("cycle %d: still checking %v", cycle, hp)
+		}
+	}
+	settleGoroutines(t, base)
+}
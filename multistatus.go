@@ -0,0 +1,83 @@
+package reachable
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MultiStatus is a snapshot of every target of a MultiChecker, as returned by
+// MultiChecker.Status. It is suitable for encoding as JSON, e.g. for a
+// dashboard API.
+type MultiStatus struct {
+	// Targets are the per-target results, sorted by hostport.
+	Targets []TargetStatus `json:"targets"`
+
+	// Reachable is how many of the targets are reachable, and Any and All
+	// aggregate them. All is false when there are no targets.
+	Reachable int  `json:"reachable"`
+	Any       bool `json:"any"`
+	All       bool `json:"all"`
+}
+
+// TargetStatus is the state of one target in a MultiStatus.
+type TargetStatus struct {
+	Hostport   string        `json:"hostport"`
+	Host       string        `json:"host"`
+	Port       int           `json:"port,omitempty"`
+	State      State         `json:"state"`
+	Reachable  bool          `json:"reachable"`
+	Latency    time.Duration `json:"latency"`
+	Error      string        `json:"error,omitempty"`
+	LastChange time.Time     `json:"lastChange"`
+}
+
+// Status returns a snapshot of every target being checked and their
+// aggregate. It is safe to call while the Checkers are running.
+func (m *MultiChecker) Status() MultiStatus {
+	var ms MultiStatus
+	for _, hp := range m.Hostports() {
+		c := m.Checker(hp)
+		if c == nil {
+			continue
+		}
+		st := c.Status()
+		t := TargetStatus{
+			Hostport:   hp,
+			State:      st.State,
+			Reachable:  st.State.reachable(),
+			Latency:    st.Latency,
+			Error:      st.Error,
+			LastChange: st.LastChange,
+		}
+		t.Host, t.Port = splitTarget(hp)
+		if t.Reachable {
+			ms.Reachable++
+		}
+		ms.Targets = append(ms.Targets, t)
+	}
+	ms.Any = ms.Reachable > 0
+	ms.All = len(ms.Targets) > 0 && ms.Reachable == len(ms.Targets)
+	return ms
+}
+
+// splitTarget returns the host and port of a hostport or URL target, with a
+// zero port if it has none.
+func splitTarget(target string) (string, int) {
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return target, 0
+		}
+		port, _ := strconv.Atoi(u.Port())
+		return u.Hostname(), port
+	}
+	host, port, err := net.SplitHostPort(withDefaultPort(target))
+	if err != nil {
+		return target, 0
+	}
+	n, _ := strconv.Atoi(port)
+	return host, n
+}
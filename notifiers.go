@@ -0,0 +1,71 @@
+package reachable
+
+type notifierEntry struct {
+	id int
+	fn func(bool)
+}
+
+// AddNotifier registers an additional reachability callback, called after
+// Notifier and NotifierCtx on every change, and returns a function that
+// removes it again. It is safe to call while the Checker is running.
+//
+// All notifiers are called in registration order from the polling goroutine
+// (or handed to Dispatch in that order), and each change is delivered to all
+// of them before the next check begins. So every notifier sees the same
+// sequence of changes, and never an older state after a newer one.
+func (c *Checker) AddNotifier(fn func(reachable bool)) (remove func()) {
+	c.mu.Lock()
+	remove = c.addNotifierLocked(fn)
+	c.mu.Unlock()
+	return remove
+}
+
+// SetNotifier replaces Notifier, and is safe to call while the Checker is
+// running, including from a notifier. The next change is delivered to fn; a
+// notification already in progress still goes to the previous Notifier.
+func (c *Checker) SetNotifier(fn func(reachable bool)) {
+	c.mu.Lock()
+	c.Notifier = fn
+	c.mu.Unlock()
+}
+
+// AddNotifierWithCurrent is like AddNotifier, but once a check has completed
+// it also calls fn with the current reachability before returning, so that
+// a UI registered mid-run starts out in the right state. Unless Dispatch is
+// set the call is synchronous. It is ordered with concurrent changes: fn
+// never sees the current state after a newer one, though it may be called
+// again with the same value if a change lands during registration. It must
+// not be called from a notifier.
+func (c *Checker) AddNotifierWithCurrent(fn func(reachable bool)) (remove func()) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.mu.Lock()
+	remove = c.addNotifierLocked(fn)
+	known := c.status.State != Unknown
+	current := c.status.Reachable
+	c.mu.Unlock()
+	if known {
+		c.dispatch(func() { fn(current) })
+	}
+	return remove
+}
+
+// addNotifierLocked registers fn. c.mu must be held.
+func (c *Checker) addNotifierLocked(fn func(reachable bool)) (remove func()) {
+	c.nextID++
+	id := c.nextID
+	c.notifiers = append(c.notifiers[:len(c.notifiers):len(c.notifiers)], notifierEntry{id, fn})
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, n := range c.notifiers {
+			if n.id == id {
+				rest := make([]notifierEntry, 0, len(c.notifiers)-1)
+				rest = append(rest, c.notifiers[:i]...)
+				c.notifiers = append(rest, c.notifiers[i+1:]...)
+				return
+			}
+		}
+	}
+}
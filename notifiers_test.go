@@ -0,0 +1,172 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotifierOrderUnderFlapping(t *testing.T) {
+	var probes, settled int32
+	down := errors.New("down")
+	c := pinged(func(context.Context) error {
+		if atomic.LoadInt32(&settled) == 0 && atomic.AddInt32(&probes, 1)%2 == 0 {
+			return down
+		}
+		return nil
+	})
+	c.Interval = time.Hour
+
+	const n = 3
+	var mu sync.Mutex
+	seen := make([][]bool, n)
+	for i := 0; i < n; i++ {
+		i := i
+		c.AddNotifier(func(r bool) {
+			mu.Lock()
+			seen[i] = append(seen[i], r)
+			mu.Unlock()
+		})
+	}
+	c.Start()
+	for i := 0; i < 200; i++ {
+		c.CheckNow()
+		time.Sleep(time.Millisecond)
+	}
+	atomic.StoreInt32(&settled, 1)
+	// past any check already in progress, which may still find it down
+	settle := c.Stats().Checks + 2
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Stats().Checks < settle {
+		if time.Now().After(deadline) {
+			t.Fatal("did not settle up")
+		}
+		c.CheckNow()
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.StopAndWait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen[0]) < 2 {
+		t.Fatalf("only %d notifications while flapping", len(seen[0]))
+	}
+	for i := range seen {
+		if !reflect.DeepEqual(seen[i], seen[0]) {
+			t.Errorf("notifier %d saw %v, notifier 0 %v", i, seen[i], seen[0])
+		}
+		for j := 1; j < len(seen[i]); j++ {
+			if seen[i][j] == seen[i][j-1] {
+				t.Errorf("notifier %d saw %v twice in a row at %d", i, seen[i][j], j)
+				break
+			}
+		}
+		if last := seen[i][len(seen[i])-1]; !last {
+			t.Errorf("notifier %d ended on %v, want true", i, last)
+		}
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	var fail int32
+	c := pinged(func(context.Context) error {
+		if atomic.LoadInt32(&fail) != 0 {
+			return errors.New("down")
+		}
+		return nil
+	})
+	for i, want := range []State{Up, Down, Up} {
+		atomic.StoreInt32(&fail, int32(i%2))
+		if st := c.Step(); st.State != want || st.Reachable != (want == Up) {
+			t.Errorf("check %d: %v, reachable %v, want %v", i, st.State, st.Reachable, want)
+		}
+	}
+
+	c = pinged(ok)
+	c.Start()
+	if err := c.WaitFirstCheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c.StopAndWait()
+	if !c.Status().Reachable {
+		t.Error("not reachable without a Notifier")
+	}
+}
+
+func TestSignificant(t *testing.T) {
+	const slow, fast = 30 * time.Millisecond, 0
+	script := []struct {
+		latency time.Duration
+		err     error
+		want    State
+	}{
+		{fast, nil, Up},
+		{slow, nil, Degraded},
+		{fast, nil, Up},
+		{slow, nil, Degraded},
+		{fast, errors.New("down"), Down},
+		{slow, nil, Degraded},
+		{fast, nil, Up},
+	}
+	step := 0
+	c := pinged(func(context.Context) error {
+		s := script[step]
+		time.Sleep(s.latency)
+		return s.err
+	})
+	c.DegradedThreshold = slow / 2
+	c.LatencySmoothing = 1
+	// ignore churn between Up and Degraded, but always report Down
+	c.Significant = func(from, to State) bool {
+		return from == Unknown || from == Down || to == Down
+	}
+	var transitions [][2]State
+	var notified []bool
+	c.OnTransition = func(from, to State) { transitions = append(transitions, [2]State{from, to}) }
+	c.Notifier = func(r bool) { notified = append(notified, r) }
+	for ; step < len(script); step++ {
+		if st := c.Step(); st.State != script[step].want {
+			t.Fatalf("check %d: %v, want %v", step, st.State, script[step].want)
+		}
+	}
+
+	wantTransitions := [][2]State{{Unknown, Up}, {Degraded, Down}, {Down, Degraded}}
+	if !reflect.DeepEqual(transitions, wantTransitions) {
+		t.Errorf("OnTransition calls %v, want %v", transitions, wantTransitions)
+	}
+	if want := []bool{true, false, true}; !reflect.DeepEqual(notified, want) {
+		t.Errorf("notified %v, want %v", notified, want)
+	}
+	if h := c.History(); len(h) != len(script) {
+		t.Errorf("%d transitions in History, want every one of %d", len(h), len(script))
+	}
+}
+
+func TestSignificantRejectingDown(t *testing.T) {
+	var fail int32
+	c := pinged(func(context.Context) error {
+		if atomic.LoadInt32(&fail) != 0 {
+			return errors.New("down")
+		}
+		return nil
+	})
+	c.Significant = func(from, to State) bool { return from == Unknown }
+	var notified []bool
+	downs := 0
+	c.Notifier = func(r bool) { notified = append(notified, r) }
+	c.OnDown = func(Cause) { downs++ }
+	c.Step()
+	atomic.StoreInt32(&fail, 1)
+	if st := c.Step(); st.State != Down {
+		t.Fatalf("%v, want Down recorded", st.State)
+	}
+	atomic.StoreInt32(&fail, 0)
+	c.Step()
+	if !reflect.DeepEqual(notified, []bool{true}) || downs != 0 {
+		t.Errorf("notified %v and OnDown called %d times for rejected changes", notified, downs)
+	}
+}
@@ -0,0 +1,84 @@
+package reachable
+
+import "time"
+
+// notify delivers a reachability change to the notifiers, subject to
+// MinNotifyInterval and MaxNotifyDelay. A held-back change schedules a
+// trailing delivery, which then delivers whatever the latest state is.
+func (c *Checker) notify(reachable bool) {
+	if c.MinNotifyInterval <= 0 {
+		c.deliver(reachable)
+		return
+	}
+	c.mu.Lock()
+	now := time.Now()
+	wait := c.deliveredAt.Add(c.MinNotifyInterval).Sub(now)
+	if c.trailingAt.IsZero() && (c.delivered < 0 || wait <= 0) {
+		c.delivered, c.deliveredAt = btoi(reachable), now
+		c.mu.Unlock()
+		c.deliver(reachable)
+		return
+	}
+	c.pending = reachable
+	if c.trailingAt.IsZero() {
+		if c.MaxNotifyDelay > 0 && wait > c.MaxNotifyDelay {
+			wait = c.MaxNotifyDelay
+		}
+		c.trailingAt = now.Add(wait)
+	}
+	c.mu.Unlock()
+}
+
+// flushTrailing makes the trailing delivery of the latest held-back change
+// once it is due, unless the notifiers already have that reachability. Like
+// the other notifications it is made from the polling goroutine: the run
+// loop and the Pool schedule a call for when it is due, and every tick, as
+// from Step, starts with one.
+func (c *Checker) flushTrailing() {
+	c.mu.Lock()
+	if c.trailingAt.IsZero() || time.Now().Before(c.trailingAt) {
+		c.mu.Unlock()
+		return
+	}
+	c.trailingAt = time.Time{}
+	reachable := c.pending
+	if btoi(reachable) == c.delivered {
+		c.mu.Unlock()
+		return
+	}
+	c.delivered, c.deliveredAt = btoi(reachable), time.Now()
+	c.mu.Unlock()
+	c.deliver(reachable)
+}
+
+// trailingDue returns when a trailing delivery is due, or the zero time if
+// none is scheduled.
+func (c *Checker) trailingDue() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.trailingAt
+}
+
+// armTrailing sets t to fire when a trailing delivery is due, and returns
+// its channel, or nil if none is scheduled.
+func (c *Checker) armTrailing(t *time.Timer) <-chan time.Time {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	at := c.trailingDue()
+	if at.IsZero() {
+		return nil
+	}
+	t.Reset(time.Until(at))
+	return t.C
+}
+
+// cancelTrailing abandons any scheduled trailing delivery and forgets what
+// was delivered. c.mu must be held.
+func (c *Checker) cancelTrailing() {
+	c.trailingAt = time.Time{}
+	c.delivered = -1
+}
@@ -0,0 +1,161 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// notification is a delivered reachability and when it was delivered.
+type notification struct {
+	at        time.Time
+	reachable bool
+}
+
+// limited returns a Checker whose probes succeed while up is set, and a
+// function returning the notifications delivered so far.
+func limited(up *int32, min, max time.Duration) (*Checker, func() []notification) {
+	var mu sync.Mutex
+	var got []notification
+	c := pinged(func(context.Context) error {
+		if atomic.LoadInt32(up) == 0 {
+			return errors.New("down")
+		}
+		return nil
+	})
+	c.MinNotifyInterval = min
+	c.MaxNotifyDelay = max
+	c.Notifier = func(r bool) {
+		mu.Lock()
+		got = append(got, notification{time.Now(), r})
+		mu.Unlock()
+	}
+	return c, func() []notification {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]notification(nil), got...)
+	}
+}
+
+// flap steps c through n changes of up, one every interval.
+func flap(c *Checker, up *int32, n int, interval time.Duration) {
+	for i := 0; i < n; i++ {
+		atomic.StoreInt32(up, int32(1-i%2))
+		c.Step()
+		time.Sleep(interval)
+	}
+}
+
+// hold keeps stepping c every few milliseconds for d without changing its
+// probe outcome, so that trailing deliveries are made as they fall due.
+func hold(c *Checker, d time.Duration) {
+	for end := time.Now().Add(d); time.Now().Before(end); {
+		c.Step()
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTrailingDeliveryAfterFlapping(t *testing.T) {
+	const min = 100 * time.Millisecond
+	for _, final := range []int32{0, 1} {
+		var up int32
+		c, got := limited(&up, min, 0)
+		flap(c, &up, 21, time.Millisecond)
+		atomic.StoreInt32(&up, final)
+		hold(c, 3*min)
+
+		n := got()
+		if len(n) == 0 || n[len(n)-1].reachable != (final == 1) {
+			t.Errorf("settled %v: notifications %v, want to end on the settled state", final == 1, n)
+		}
+		if len(n) > 3 {
+			t.Errorf("settled %v: %d notifications for a burst within MinNotifyInterval", final == 1, len(n))
+		}
+	}
+}
+
+func TestMinNotifyIntervalFloor(t *testing.T) {
+	const min = 80 * time.Millisecond
+	var up int32
+	c, got := limited(&up, min, 0)
+	flap(c, &up, 40, 10*time.Millisecond)
+	hold(c, 2*min)
+	n := got()
+	for i := 1; i < len(n); i++ {
+		// timers may fire a little early relative to our clock readings
+		if gap := n[i].at.Sub(n[i-1].at); gap < min-10*time.Millisecond {
+			t.Errorf("notifications %d and %d only %v apart, want at least %v", i-1, i, gap, min)
+		}
+	}
+}
+
+func TestMaxNotifyDelayBound(t *testing.T) {
+	const min, max = 400 * time.Millisecond, 100 * time.Millisecond
+	var up int32 = 1
+	c, got := limited(&up, min, max)
+	c.Step() // notified at once
+	atomic.StoreInt32(&up, 0)
+	changed := time.Now()
+	hold(c, 3*max) // held back by MinNotifyInterval, but only for MaxNotifyDelay
+	n := got()
+	if len(n) != 2 || n[1].reachable {
+		t.Fatalf("notifications %v, want up then down", n)
+	}
+	if delay := n[1].at.Sub(changed); delay > max+50*time.Millisecond {
+		t.Errorf("sustained change delivered after %v, want within MaxNotifyDelay %v", delay, max)
+	}
+
+	// continuous flapping is reported at least every MinNotifyInterval,
+	// whenever the state then differs from the one last delivered
+	flap(c, &up, 60, 7*time.Millisecond)
+	hold(c, 3*max)
+	n = got()
+	if len(n) < 4 {
+		t.Errorf("only %d notifications while flapping", len(n))
+	}
+	for i := 2; i < len(n); i++ { // from the first of the flapping
+		if gap := n[i].at.Sub(n[i-1].at); gap > min+50*time.Millisecond {
+			t.Errorf("notifications %d and %d %v apart while flapping, want at most MinNotifyInterval %v", i-1, i, gap, min)
+		}
+	}
+	if last := n[len(n)-1]; last.reachable != (atomic.LoadInt32(&up) == 1) {
+		t.Errorf("ended on %v, want the settled state", last.reachable)
+	}
+}
+
+func TestTrailingDeliveryFromRunLoop(t *testing.T) {
+	const min = 200 * time.Millisecond
+	for _, pool := range []*Pool{nil, NewPool(1)} {
+		var up int32 = 1
+		c, _ := limited(&up, min, 0)
+		c.Pool = pool
+		c.Interval = time.Hour // no check but the first and CheckNow's
+		var checker, notifier string
+		c.OnTransition = func(from, to State) { checker = goroutineID() }
+		notified := make(chan struct{}, 10)
+		c.SetNotifier(func(bool) {
+			notifier = goroutineID()
+			notified <- struct{}{}
+		})
+		c.Start()
+		<-notified
+		atomic.StoreInt32(&up, 0)
+		changed := time.Now()
+		c.CheckNow() // found down, but held back by MinNotifyInterval
+		select {
+		case <-notified:
+		case <-time.After(5 * min):
+			t.Fatalf("pool %v: held-back change never delivered", pool != nil)
+		}
+		c.StopAndWait()
+		if delay := time.Since(changed); delay < min/2 || delay > min+100*time.Millisecond {
+			t.Errorf("pool %v: held-back change delivered after %v, want about MinNotifyInterval %v", pool != nil, delay, min)
+		}
+		if notifier != checker {
+			t.Errorf("pool %v: trailing delivery on %q, want the polling goroutine %q", pool != nil, notifier, checker)
+		}
+	}
+}
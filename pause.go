@@ -0,0 +1,53 @@
+package reachable
+
+import "sync/atomic"
+
+// allPaused is 1 between PauseAll and ResumeAll. Accessed atomically.
+var allPaused int32
+
+// Pause suspends probing until Resume, e.g. while the device is in airplane
+// mode and probing is pointless. While paused, the Checker keeps running and
+// holds its last state, as with Enabled returning false, including for
+// checks requested by CheckNow. Pausing a Checker that is not running
+// pauses it from when it is started.
+func (c *Checker) Pause() {
+	c.mu.Lock()
+	c.paused = true
+	c.mu.Unlock()
+}
+
+// Resume ends a Pause and checks at once, so that the state held while
+// paused is refreshed. A Checker paused by PauseAll stays paused until
+// ResumeAll.
+func (c *Checker) Resume() {
+	c.mu.Lock()
+	c.paused = false
+	c.mu.Unlock()
+	c.CheckNow()
+}
+
+// Paused reports whether probing is suspended by Pause or PauseAll.
+func (c *Checker) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused || atomic.LoadInt32(&allPaused) == 1
+}
+
+// PauseAll suspends probing by every Checker in the process, as with Pause,
+// including those started before ResumeAll is called.
+func PauseAll() {
+	atomic.StoreInt32(&allPaused, 1)
+}
+
+// ResumeAll ends a PauseAll and checks at once on every running Checker that
+// is not paused by Pause of its own.
+func ResumeAll() {
+	if !atomic.CompareAndSwapInt32(&allPaused, 1, 0) {
+		return
+	}
+	for _, c := range Running() {
+		if !c.Paused() {
+			c.CheckNow()
+		}
+	}
+}
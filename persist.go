@@ -0,0 +1,62 @@
+package reachable
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// savedState is the persisted form of a Checker's last-known state.
+type savedState struct {
+	State       State     `json:"state"`
+	Reachable   bool      `json:"reachable"`
+	LastChange  time.Time `json:"lastChange"`
+	LastSuccess time.Time `json:"lastSuccess"`
+	LastFailure time.Time `json:"lastFailure"`
+}
+
+// MarshalState encodes the last-known reachability state of c, and when it
+// last changed, for RestoreState to load after a restart. It returns nil if no
+// check has completed yet, since there is nothing worth restoring.
+func (c *Checker) MarshalState() ([]byte, error) {
+	c.mu.Lock()
+	st := c.status
+	c.mu.Unlock()
+	if st.State == Unknown {
+		return nil, nil
+	}
+	return json.Marshal(savedState{
+		State:       st.State,
+		Reachable:   st.Reachable,
+		LastChange:  st.LastChange,
+		LastSuccess: st.LastSuccess,
+		LastFailure: st.LastFailure,
+	})
+}
+
+// RestoreState loads state saved by MarshalState. It must be called before
+// Start. The restored state is reported by Status until the first check, and
+// that check only notifies, and calls OnTransition, if its result differs
+// from the restored state, so restarting does not re-report a transition that
+// was already seen. Empty data is ignored.
+func (c *Checker) RestoreState(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var saved savedState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running {
+		return errors.New("reachable: RestoreState called on a running Checker")
+	}
+	c.status.State = saved.State
+	c.status.Reachable = saved.Reachable
+	c.status.LastChange = saved.LastChange
+	c.status.LastSuccess = saved.LastSuccess
+	c.status.LastFailure = saved.LastFailure
+	c.restored = saved.State != Unknown
+	return nil
+}
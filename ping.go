@@ -0,0 +1,16 @@
+package reachable
+
+import "net"
+
+// Ping makes a single TCP connection to hostport, with DefaultPort if it has
+// none, and reports whether it succeeded within DefaultTimeout. It starts no
+// goroutine and uses no Checker. It deliberately skips the interface check
+// made by a Checker: a missing interface simply makes the dial fail.
+func Ping(hostport string) bool {
+	conn, err := net.DialTimeout("tcp", withDefaultPort(hostport), defaultTimeout())
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
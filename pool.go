@@ -0,0 +1,261 @@
+package reachable
+
+import (
+	"container/heap"
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Pool runs the checks of many Checkers on a small, fixed set of worker
+// goroutines rather than one goroutine per Checker. A single scheduler
+// goroutine keeps the Checkers in a min-heap ordered by when each is next due,
+// and hands due Checkers to the workers. This scales to thousands of Checkers
+// without thousands of goroutines and timers.
+//
+// Checkers join a Pool by setting their Pool field before Start; Start, Stop,
+// CheckNow and the notifiers behave just as they do without a Pool. Callbacks
+// run on the worker goroutines, so a slow callback occupies a worker. A Pool
+// can also make one-off checks of unstarted Checkers; see Schedule.
+//
+//    pool := reachable.NewPool(4)
+//    for _, hp := range hosts {
+//        c := &reachable.Checker{Hostport: hp, Pool: pool, Notifier: ...}
+//        c.Start()
+//    }
+//
+type Pool struct {
+	work chan *poolEntry
+
+	mu      sync.Mutex
+	due     dueHeap
+	entries map[*Checker]*poolEntry
+	wake    chan struct{}
+}
+
+// poolEntry tracks one Checker within a Pool.
+type poolEntry struct {
+	c     *Checker
+	ctx   context.Context // of the run, as passed to tick
+	next  time.Time
+	index int // in the heap, or -1 while running or removed
+
+	// checkAt is when the next check is due. next is sooner when a
+	// notification held back by MinNotifyInterval is due first.
+	checkAt time.Time
+
+	forced  bool // the next check was requested by CheckNow
+	removed bool
+
+	// done is set for a one-off check scheduled with Schedule, and called
+	// with its result instead of the entry being rescheduled.
+	done func(Status)
+
+	// busy is set while a worker runs a check.
+	busy bool
+}
+
+// NewPool returns a Pool with the given number of worker goroutines. If
+// workers is zero or negative, runtime.NumCPU() workers are used. The Pool's
+// goroutines run for the life of the program.
+func NewPool(workers int) *Pool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	p := &Pool{
+		work:    make(chan *poolEntry),
+		entries: make(map[*Checker]*poolEntry),
+		wake:    make(chan struct{}, 1),
+	}
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	go p.schedule()
+	return p
+}
+
+func (p *Pool) add(c *Checker, ctx context.Context) {
+	first := time.Now().Add(c.firstInterval())
+	e := &poolEntry{c: c, ctx: ctx, next: first, checkAt: first}
+	p.mu.Lock()
+	p.entries[c] = e
+	heap.Push(&p.due, e)
+	p.mu.Unlock()
+	p.poke()
+}
+
+// remove takes c out of the Pool, and reports whether a check of c is in
+// progress, in which case the worker ends the run of c once it completes.
+// It does not wait, as it may be called from a callback of that check.
+func (p *Pool) remove(c *Checker) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.entries[c]
+	if e == nil {
+		return false
+	}
+	delete(p.entries, c)
+	e.removed = true
+	if e.index >= 0 {
+		heap.Remove(&p.due, e.index)
+	}
+	return e.busy
+}
+
+// Schedule makes a single check of c on the Pool's workers once after has
+// passed, and then calls done with the resulting Status from the worker
+// goroutine. It is meant for bulk one-off checks, which then share the Pool's
+// workers and its single timer instead of each needing a goroutine and timer
+// of its own. The check is made as by c.Step, so c must not be started, and
+// must not be scheduled again before done is called; c.Pool is not used.
+func (p *Pool) Schedule(c *Checker, after time.Duration, done func(Status)) {
+	e := &poolEntry{c: c, next: time.Now().Add(after), done: done}
+	p.mu.Lock()
+	heap.Push(&p.due, e)
+	p.mu.Unlock()
+	p.poke()
+}
+
+// Check is like Schedule for a check made as soon as a worker is free, and
+// returns a channel that receives its Status.
+func (p *Pool) Check(c *Checker) <-chan Status {
+	ch := make(chan Status, 1)
+	p.Schedule(c, 0, func(st Status) { ch <- st })
+	return ch
+}
+
+func (p *Pool) checkNow(c *Checker) {
+	p.mu.Lock()
+	if e := p.entries[c]; e != nil {
+		e.forced = true
+		if e.index >= 0 {
+			e.next = time.Now()
+			heap.Fix(&p.due, e.index)
+		}
+	}
+	p.mu.Unlock()
+	p.poke()
+}
+
+// poke wakes the scheduler to look at the heap again.
+func (p *Pool) poke() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// schedule hands each Checker to a worker when it is due.
+func (p *Pool) schedule() {
+	t := time.NewTimer(time.Hour)
+	for {
+		p.mu.Lock()
+		var e *poolEntry
+		wait := time.Hour
+		if len(p.due) > 0 {
+			wait = time.Until(p.due[0].next)
+			if wait <= 0 {
+				e = heap.Pop(&p.due).(*poolEntry)
+			}
+		}
+		p.mu.Unlock()
+
+		if e != nil {
+			p.work <- e
+			continue
+		}
+
+		t.Reset(wait)
+		select {
+		case <-t.C:
+		case <-p.wake:
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
+				}
+			}
+		}
+	}
+}
+
+func (p *Pool) worker() {
+	for e := range p.work {
+		if e.done != nil {
+			e.done(e.c.Step())
+			continue
+		}
+		p.mu.Lock()
+		forced := e.forced
+		e.forced = false
+		if e.removed {
+			p.mu.Unlock()
+			continue
+		}
+		e.busy = true
+		e.c.wg.Add(1) // so that StopAndWait waits for the check
+		p.mu.Unlock()
+
+		checked := forced || !time.Now().Before(e.checkAt)
+		if checked {
+			e.c.tick(e.ctx, forced)
+		} else {
+			e.c.flushTrailing()
+		}
+		next := e.checkAt
+		if checked {
+			next = time.Now().Add(e.c.nextInterval())
+		}
+		if at := e.c.trailingDue(); !at.IsZero() && at.Before(next) {
+			next = at
+		}
+
+		p.mu.Lock()
+		e.busy = false
+		stopped := e.removed
+		if !stopped {
+			if checked {
+				e.checkAt = next
+			}
+			e.next = next
+			if e.forced {
+				e.next = time.Now()
+			}
+			heap.Push(&p.due, e)
+		}
+		p.mu.Unlock()
+		if stopped {
+			// stopped during the check, which remove left to end here
+			e.c.end()
+		}
+		e.c.wg.Done()
+		p.poke()
+	}
+}
+
+// dueHeap is a min-heap of pool entries by next due time.
+type dueHeap []*poolEntry
+
+func (h dueHeap) Len() int           { return len(h) }
+func (h dueHeap) Less(i, j int) bool { return h[i].next.Before(h[j].next) }
+func (h dueHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *dueHeap) Push(x interface{}) {
+	e := x.(*poolEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *dueHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	e.index = -1
+	*h = old[:len(old)-1]
+	return e
+}
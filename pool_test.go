@@ -0,0 +1,125 @@
+package reachable
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// benchmarkCheckers starts n Checkers with pool, waits for the first check of
+// each, and stops them all again, b.N times.
+func benchmarkCheckers(b *testing.B, n int, pool *Pool) {
+	checkers := make([]*Checker, n)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for j := range checkers {
+			var once sync.Once
+			c := pinged(ok)
+			c.Interval = time.Hour
+			c.Pool = pool
+			c.Notifier = func(bool) { once.Do(wg.Done) }
+			checkers[j] = c
+			c.Start()
+		}
+		wg.Wait()
+		b.ReportMetric(float64(runtime.NumGoroutine()), "goroutines")
+		for _, c := range checkers {
+			c.StopAndWait()
+		}
+	}
+}
+
+func BenchmarkGoroutinePerChecker10k(b *testing.B) {
+	benchmarkCheckers(b, 10000, nil)
+}
+
+func BenchmarkPool10k(b *testing.B) {
+	benchmarkCheckers(b, 10000, NewPool(0))
+}
+
+func TestPoolChecks(t *testing.T) {
+	pool := NewPool(2)
+	const n = 20
+	var wg sync.WaitGroup
+	wg.Add(n)
+	checkers := make([]*Checker, n)
+	for i := range checkers {
+		var once sync.Once
+		c := pinged(ok)
+		c.Pool = pool
+		c.Notifier = func(r bool) {
+			if r {
+				once.Do(wg.Done)
+			}
+		}
+		checkers[i] = c
+		c.Start()
+	}
+	within(t, 5*time.Second, "first checks", wg.Wait)
+	for _, c := range checkers {
+		within(t, 5*time.Second, "StopAndWait", c.StopAndWait)
+	}
+}
+
+// benchmarkOneOff makes n one-off checks of unstarted Checkers with check,
+// b.N times.
+func benchmarkOneOff(b *testing.B, n int, check func(c *Checker, done func(Status))) {
+	checkers := make([]*Checker, n)
+	for i := range checkers {
+		checkers[i] = pinged(ok)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for _, c := range checkers {
+			check(c, func(Status) { wg.Done() })
+		}
+		wg.Wait()
+	}
+}
+
+func BenchmarkOneOffGoroutines(b *testing.B) {
+	benchmarkOneOff(b, 1000, func(c *Checker, done func(Status)) {
+		go func() {
+			time.Sleep(time.Millisecond)
+			done(c.Step())
+		}()
+	})
+}
+
+func BenchmarkOneOffPoolSchedule(b *testing.B) {
+	pool := NewPool(0)
+	benchmarkOneOff(b, 1000, func(c *Checker, done func(Status)) {
+		pool.Schedule(c, time.Millisecond, done)
+	})
+}
+
+func TestPoolSchedule(t *testing.T) {
+	pool := NewPool(2)
+	c := pinged(ok)
+	st := <-pool.Check(c)
+	if st.State != Up {
+		t.Errorf("Check: %v", st.State)
+	}
+	done := make(chan Status, 1)
+	start := time.Now()
+	pool.Schedule(pinged(failing(errors.New("down"))), 50*time.Millisecond, func(st Status) { done <- st })
+	select {
+	case st := <-done:
+		if st.State != Down {
+			t.Errorf("Schedule: %v", st.State)
+		}
+		if d := time.Since(start); d < 50*time.Millisecond {
+			t.Errorf("scheduled check made after %v, before its delay", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled check not made")
+	}
+}
@@ -0,0 +1,55 @@
+package reachable
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PortStatus is the result of checking a single port when Checker.Ports is set.
+type PortStatus struct {
+	Port      int           `json:"port"`
+	Reachable bool          `json:"reachable"`
+	Latency   time.Duration `json:"latency"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// probePorts probes every port in Ports on the Hostport host concurrently,
+// and fails if any of them fails.
+func (c *Checker) probePorts(ctx context.Context, res *result) error {
+	host := c.Hostport
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	ports := make([]PortStatus, len(c.Ports))
+	errs := make([]error, len(c.Ports))
+	var wg sync.WaitGroup
+	for i, port := range c.Ports {
+		wg.Add(1)
+		go func(i, port int) {
+			defer wg.Done()
+			r := result{source: res.source, dials: res.dials}
+			start := time.Now()
+			err := c.probeHost(ctx, net.JoinHostPort(host, strconv.Itoa(port)), &r)
+			ports[i] = PortStatus{Port: port, Reachable: err == nil, Latency: time.Since(start)}
+			if err != nil {
+				ports[i].Error = err.Error()
+				errs[i] = fmt.Errorf("port %d: %w", port, err)
+			}
+		}(i, port)
+	}
+	wg.Wait()
+
+	res.host = host
+	res.ports = ports
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
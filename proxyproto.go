@@ -0,0 +1,88 @@
+package reachable
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// proxySignature starts every PROXY protocol version 2 header.
+var proxySignature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// sendProxyHeader writes a PROXY protocol header for conn, if ProxyProtocol
+// is set.
+func (c *Checker) sendProxyHeader(ctx context.Context, conn net.Conn) error {
+	var header []byte
+	switch c.ProxyProtocol {
+	case 0:
+		return nil
+	case 1:
+		header = proxyHeaderV1(conn.LocalAddr(), conn.RemoteAddr())
+	case 2:
+		header = proxyHeaderV2(conn.LocalAddr(), conn.RemoteAddr())
+	default:
+		return &classError{ErrConfig, fmt.Errorf("reachable: unsupported PROXY protocol version %d", c.ProxyProtocol)}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetWriteDeadline(deadline)
+		defer conn.SetWriteDeadline(time.Time{})
+	}
+	_, err := conn.Write(header)
+	return err
+}
+
+// tcpAddrs returns src and dst as TCP addresses of the same family, or false
+// if they are not.
+func tcpAddrs(src, dst net.Addr) (s, d *net.TCPAddr, v4 bool, ok bool) {
+	s, ok1 := src.(*net.TCPAddr)
+	d, ok2 := dst.(*net.TCPAddr)
+	if !ok1 || !ok2 {
+		return nil, nil, false, false
+	}
+	s4, d4 := s.IP.To4(), d.IP.To4()
+	if (s4 == nil) != (d4 == nil) {
+		return nil, nil, false, false
+	}
+	return s, d, s4 != nil, true
+}
+
+// proxyHeaderV1 returns the human-readable version 1 header line.
+func proxyHeaderV1(src, dst net.Addr) []byte {
+	s, d, v4, ok := tcpAddrs(src, dst)
+	if !ok {
+		return []byte("PROXY UNKNOWN\r\n")
+	}
+	proto := "TCP6"
+	if v4 {
+		proto = "TCP4"
+	}
+	return []byte(fmt.Sprintf("PROXY %s %s %s %d %d\r\n", proto, s.IP, d.IP, s.Port, d.Port))
+}
+
+// proxyHeaderV2 returns the binary version 2 header. Connections that are not
+// TCP are sent with the LOCAL command and no address block.
+func proxyHeaderV2(src, dst net.Addr) []byte {
+	header := append([]byte(nil), proxySignature...)
+	s, d, v4, ok := tcpAddrs(src, dst)
+	if !ok {
+		return append(header, 0x20, 0x00, 0, 0)
+	}
+	var addrs []byte
+	if v4 {
+		header = append(header, 0x21, 0x11)
+		addrs = append(append(addrs, s.IP.To4()...), d.IP.To4()...)
+	} else {
+		header = append(header, 0x21, 0x21)
+		addrs = append(append(addrs, s.IP.To16()...), d.IP.To16()...)
+	}
+	var ports [4]byte
+	binary.BigEndian.PutUint16(ports[0:], uint16(s.Port))
+	binary.BigEndian.PutUint16(ports[2:], uint16(d.Port))
+	addrs = append(addrs, ports[:]...)
+	var n [2]byte
+	binary.BigEndian.PutUint16(n[:], uint16(len(addrs)))
+	header = append(header, n[:]...)
+	return append(header, addrs...)
+}
@@ -0,0 +1,106 @@
+package reachable
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestProxyHeaders(t *testing.T) {
+	v4src := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 56324}
+	v4dst := &net.TCPAddr{IP: net.IPv4(198, 51, 100, 7), Port: 443}
+	v6src := &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324}
+	v6dst := &net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 443}
+	pipe, _ := net.Pipe()
+	defer pipe.Close()
+
+	sig := "\r\n\r\n\x00\r\nQUIT\n"
+	tests := []struct {
+		name     string
+		src, dst net.Addr
+		v1, v2   string
+	}{
+		{"tcp4", v4src, v4dst,
+			"PROXY TCP4 192.0.2.1 198.51.100.7 56324 443\r\n",
+			sig + "\x21\x11\x00\x0c" + "\xc0\x00\x02\x01" + "\xc6\x33\x64\x07" + "\xdc\x04\x01\xbb"},
+		{"tcp6", v6src, v6dst,
+			"PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n",
+			sig + "\x21\x21\x00\x24" +
+				"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01" +
+				"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02" +
+				"\xdc\x04\x01\xbb"},
+		{"mixed families", v4src, v6dst, "PROXY UNKNOWN\r\n", sig + "\x20\x00\x00\x00"},
+		{"not tcp", pipe.LocalAddr(), pipe.RemoteAddr(), "PROXY UNKNOWN\r\n", sig + "\x20\x00\x00\x00"},
+	}
+	for _, tt := range tests {
+		if got := proxyHeaderV1(tt.src, tt.dst); string(got) != tt.v1 {
+			t.Errorf("%s: v1 header %q, want %q", tt.name, got, tt.v1)
+		}
+		if got := proxyHeaderV2(tt.src, tt.dst); string(got) != tt.v2 {
+			t.Errorf("%s: v2 header % x, want % x", tt.name, got, tt.v2)
+		}
+	}
+}
+
+func TestProxyHeaderSent(t *testing.T) {
+	for _, version := range []int{1, 2} {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		received := make(chan []byte, 1)
+		accepted := make(chan net.Addr, 1)
+		go func() {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			accepted <- conn.RemoteAddr()
+			b, _ := io.ReadAll(conn)
+			received <- b
+		}()
+		c := &Checker{
+			Hostport:           l.Addr().String(),
+			ProxyProtocol:      version,
+			SkipInterfaceCheck: true,
+		}
+		if st := c.Step(); st.State != Up {
+			t.Errorf("v%d: %v with %v, want up", version, st.State, st.Err)
+		}
+		client := <-accepted
+		want := proxyHeaderV1(client, l.Addr())
+		if version == 2 {
+			want = proxyHeaderV2(client, l.Addr())
+		}
+		if got := <-received; !bytes.Equal(got, want) {
+			t.Errorf("v%d: server received %q, want %q", version, got, want)
+		}
+		l.Close()
+	}
+}
+
+func TestProxyHeaderOverPipe(t *testing.T) {
+	received := make(chan []byte, 1)
+	c := &Checker{
+		Hostport:           "example.com:80",
+		ProxyProtocol:      1,
+		SkipInterfaceCheck: true,
+		Dialer: dialFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			a, b := net.Pipe()
+			go func() {
+				buf, _ := io.ReadAll(b)
+				received <- buf
+			}()
+			return a, nil
+		}),
+	}
+	if st := c.Step(); st.State != Up {
+		t.Errorf("%v with %v, want up", st.State, st.Err)
+	}
+	if got := string(<-received); got != "PROXY UNKNOWN\r\n" {
+		t.Errorf("received %q, want the UNKNOWN v1 header", got)
+	}
+}
@@ -0,0 +1,227 @@
+// Package quicprobe checks that a host accepts QUIC, the UDP transport of
+// HTTP/3, which firewalls often block separately from TCP. A TCP or TLS probe
+// of port 443 succeeds on such networks while HTTP/3 clients stall, so this
+// probe sends UDP instead. It uses only the standard library, keeping the core
+// package free of a QUIC dependency.
+//
+//    p := &quicprobe.Probe{Hostport: "www.google.com:443"}
+//    c := reachable.Checker{
+//        Hostport: "www.google.com:443",
+//        PingFunc: p.Ping,
+//    }
+//    c.Start()
+//
+// The probe sends a QUIC Initial packet carrying a reserved version number,
+// which every QUIC server must answer with a Version Negotiation packet
+// listing the versions it supports (RFC 9000, section 6). A reply therefore
+// proves that UDP datagrams get through in both directions and that a QUIC
+// server is listening, in a single round trip and without a TLS handshake.
+// Because no handshake is made, the server's certificate is not verified and
+// no ALPN protocol is negotiated; Result reports the supported QUIC versions
+// instead.
+package quicprobe
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+var (
+	// DefaultTimeout bounds a Check whose context has no deadline.
+	DefaultTimeout = time.Second * 5
+
+	// DefaultResend is how long to wait for a reply before sending the probe
+	// packet again, since UDP datagrams may be lost, when Probe.Resend is
+	// unset.
+	DefaultResend = time.Millisecond * 500
+)
+
+// ErrNotQUIC is returned when the host replied with something other than a
+// QUIC Version Negotiation packet for the probe.
+var ErrNotQUIC = errors.New("quicprobe: reply is not a QUIC version negotiation")
+
+// Version1 is QUIC version 1 (RFC 9000), and Version2 QUIC version 2 (RFC
+// 9369), as listed in Result.Versions.
+const (
+	Version1 uint32 = 0x00000001
+	Version2 uint32 = 0x6b3343cf
+)
+
+// probeVersion is reserved for exercising version negotiation (RFC 9000,
+// section 15), so no server will ever accept it.
+const probeVersion uint32 = 0x1a2a3a4a
+
+// minDatagram is the smallest datagram carrying an Initial packet that servers
+// must respond to.
+const minDatagram = 1200
+
+// Result describes a successful probe.
+type Result struct {
+	// Addr is the UDP address that replied.
+	Addr net.Addr
+
+	// Latency is the round trip time of the probe packet that was answered.
+	Latency time.Duration
+
+	// Versions are the QUIC versions the server supports, most preferred
+	// first, possibly including reserved values servers add as greasing.
+	Versions []uint32
+}
+
+// Supports returns true if the server listed version v.
+func (r Result) Supports(v uint32) bool {
+	for _, sv := range r.Versions {
+		if sv == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Probe checks Hostport over QUIC. Its Ping method can be used directly as a
+// reachable.Checker PingFunc.
+type Probe struct {
+	// Hostport is the host and UDP port to probe, usually port 443.
+	Hostport string
+
+	// Resend is how long to wait for a reply before sending the probe packet
+	// again. If zero, uses DefaultResend.
+	Resend time.Duration
+}
+
+// Ping probes Hostport and returns nil if a QUIC server answered. It has the
+// signature of a reachable.Checker PingFunc.
+func (p *Probe) Ping(ctx context.Context) error {
+	_, err := p.Check(ctx)
+	return err
+}
+
+// Check probes Hostport until the context expires, resending the probe
+// packet after every Resend interval without a reply.
+func (p *Probe) Check(ctx context.Context) (Result, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	resend := p.Resend
+	if resend <= 0 {
+		resend = DefaultResend
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", p.Hostport)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+	// unblock the read as soon as the context is done, not just at its deadline
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	dcid, scid := make([]byte, 8), make([]byte, 8)
+	if _, err := rand.Read(dcid); err != nil {
+		return Result{}, err
+	}
+	if _, err := rand.Read(scid); err != nil {
+		return Result{}, err
+	}
+	packet := initialPacket(dcid, scid)
+
+	buf := make([]byte, 1500)
+	deadline, _ := ctx.Deadline()
+	for {
+		sent := time.Now()
+		if _, err := conn.Write(packet); err != nil {
+			return Result{}, err
+		}
+		wait := sent.Add(resend)
+		if wait.After(deadline) {
+			wait = deadline
+		}
+		conn.SetReadDeadline(wait)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				if ctx.Err() != nil {
+					return Result{}, fmt.Errorf("quicprobe: no reply from %s: %w", p.Hostport, ctx.Err())
+				}
+				var nerr net.Error
+				if errors.As(err, &nerr) && nerr.Timeout() {
+					break // resend
+				}
+				return Result{}, err
+			}
+			versions, err := parseVersionNegotiation(buf[:n], scid, dcid)
+			if err != nil {
+				return Result{}, err
+			}
+			if versions == nil {
+				// a stray datagram, e.g. a reply to an earlier probe
+				continue
+			}
+			return Result{Addr: conn.RemoteAddr(), Latency: time.Since(sent), Versions: versions}, nil
+		}
+	}
+}
+
+// initialPacket returns a datagram holding the long header of an Initial
+// packet (RFC 9000, section 17.2) for probeVersion, padded to minDatagram.
+// Servers do not parse past the connection IDs of an unsupported version, so
+// the rest is left as zeros.
+func initialPacket(dcid, scid []byte) []byte {
+	b := make([]byte, minDatagram)
+	b[0] = 0xc0 // long header, fixed bit, Initial
+	binary.BigEndian.PutUint32(b[1:], probeVersion)
+	i := 5
+	b[i] = byte(len(dcid))
+	i += 1 + copy(b[i+1:], dcid)
+	b[i] = byte(len(scid))
+	copy(b[i+1:], scid)
+	return b
+}
+
+// parseVersionNegotiation returns the versions listed in a Version
+// Negotiation packet (RFC 9000, section 17.2.1) that answers a probe sent
+// with the given connection IDs, which the reply swaps. It returns nil
+// without an error for a valid reply to some other probe.
+func parseVersionNegotiation(b, dcid, scid []byte) ([]uint32, error) {
+	if len(b) < 7 || b[0]&0x80 == 0 || binary.BigEndian.Uint32(b[1:]) != 0 {
+		return nil, ErrNotQUIC
+	}
+	b = b[5:]
+	var ids [2][]byte
+	for i := range ids {
+		n := int(b[0])
+		if len(b) < 1+n {
+			return nil, ErrNotQUIC
+		}
+		ids[i], b = b[1:1+n], b[1+n:]
+		if len(b) == 0 && i == 0 {
+			return nil, ErrNotQUIC
+		}
+	}
+	if len(b) == 0 || len(b)%4 != 0 {
+		return nil, ErrNotQUIC
+	}
+	if string(ids[0]) != string(dcid) || string(ids[1]) != string(scid) {
+		return nil, nil
+	}
+	versions := make([]uint32, 0, len(b)/4)
+	for ; len(b) > 0; b = b[4:] {
+		versions = append(versions, binary.BigEndian.Uint32(b))
+	}
+	return versions, nil
+}
@@ -0,0 +1,107 @@
+package quicprobe
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// server answers probes on a local UDP port with reply, which is passed the
+// probe's destination and source connection IDs, and returns its address.
+// A nil reply from the function sends nothing.
+func server(t *testing.T, reply func(n int, dcid, scid []byte) [][]byte) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 2048)
+		for n := 0; ; n++ {
+			size, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			b := buf[:size]
+			if size < minDatagram || b[0] != 0xc0 || binary.BigEndian.Uint32(b[1:]) != probeVersion {
+				t.Errorf("probe packet % x is not a padded Initial for the reserved version", b[:16])
+				continue
+			}
+			dcid := b[6 : 6+int(b[5])]
+			scid := b[7+len(dcid) : 7+len(dcid)+int(b[6+len(dcid)])]
+			for _, d := range reply(n, dcid, scid) {
+				pc.WriteTo(d, addr)
+			}
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+// negotiation returns a Version Negotiation packet with the given connection
+// IDs and versions.
+func negotiation(dcid, scid []byte, versions ...uint32) []byte {
+	b := []byte{0x80, 0, 0, 0, 0, byte(len(dcid))}
+	b = append(b, dcid...)
+	b = append(b, byte(len(scid)))
+	b = append(b, scid...)
+	for _, v := range versions {
+		var vb [4]byte
+		binary.BigEndian.PutUint32(vb[:], v)
+		b = append(b, vb[:]...)
+	}
+	return b
+}
+
+func TestCheck(t *testing.T) {
+	addr := server(t, func(n int, dcid, scid []byte) [][]byte {
+		if n == 0 {
+			return nil // the first probe is lost
+		}
+		return [][]byte{
+			negotiation([]byte("other"), []byte("probe"), Version1), // for an earlier probe
+			negotiation(scid, dcid, Version2, Version1),
+		}
+	})
+	p := &Probe{Hostport: addr, Resend: 20 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := p.Check(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Versions) != 2 || !res.Supports(Version1) || !res.Supports(Version2) || res.Supports(probeVersion) {
+		t.Errorf("Versions %#x, want QUIC versions 2 and 1", res.Versions)
+	}
+	if res.Addr.String() != addr {
+		t.Errorf("Addr %v, want %s", res.Addr, addr)
+	}
+}
+
+func TestCheckNotQUIC(t *testing.T) {
+	addr := server(t, func(int, []byte, []byte) [][]byte {
+		return [][]byte{[]byte("HTTP/1.1 400 Bad Request\r\n\r\n")}
+	})
+	p := &Probe{Hostport: addr}
+	if err := p.Ping(context.Background()); !errors.Is(err, ErrNotQUIC) {
+		t.Errorf("Ping() = %v, want ErrNotQUIC", err)
+	}
+}
+
+func TestCheckNoReply(t *testing.T) {
+	addr := server(t, func(int, []byte, []byte) [][]byte { return nil })
+	p := &Probe{Hostport: addr, Resend: 20 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := p.Ping(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Ping() = %v, want the context deadline", err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("Ping took %v, past its 100ms deadline", took)
+	}
+}
@@ -0,0 +1,66 @@
+package reachable
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HostStatus is the result of checking a single entry of Hostports when
+// Checker.Quorum is set.
+type HostStatus struct {
+	Host      string        `json:"host"`
+	Reachable bool          `json:"reachable"`
+	Latency   time.Duration `json:"latency"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// probeQuorum probes every entry of hosts concurrently, and succeeds if at
+// least Quorum of them do.
+func (c *Checker) probeQuorum(ctx context.Context, hosts []string, res *result) error {
+	statuses := make([]HostStatus, len(hosts))
+	results := make([]result, len(hosts))
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i, hp := range hosts {
+		wg.Add(1)
+		go func(i int, hp string) {
+			defer wg.Done()
+			results[i].source = res.source
+			results[i].dials = res.dials
+			start := time.Now()
+			err := c.probeHost(ctx, hp, &results[i])
+			statuses[i] = HostStatus{Host: hp, Reachable: err == nil, Latency: time.Since(start)}
+			if err != nil {
+				statuses[i].Error = err.Error()
+				errs[i] = err
+			}
+		}(i, hp)
+	}
+	wg.Wait()
+
+	res.hosts = statuses
+	reached := 0
+	for _, err := range errs {
+		if err == nil {
+			reached++
+		}
+	}
+	// report the details of the first host that agrees with the outcome
+	best := 0
+	for i, err := range errs {
+		if (err == nil) == (reached >= c.Quorum) {
+			best = i
+			break
+		}
+	}
+	r := results[best]
+	res.host, res.target, res.addr = r.host, r.target, r.addr
+	res.resolved, res.family, res.alpn = r.resolved, r.family, r.alpn
+	res.health, res.healthWarn = r.health, r.healthWarn
+	if reached >= c.Quorum {
+		return nil
+	}
+	return fmt.Errorf("%d of %d hosts reachable, quorum %d: %s: %w", reached, len(hosts), c.Quorum, hosts[best], errs[best])
+}
@@ -0,0 +1,99 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// quorumHosts returns two hosts accepting connections and one refusing them,
+// in that order with the refusing one in the middle.
+func quorumHosts(t *testing.T) []string {
+	t.Helper()
+	var hosts []string
+	for i := 0; i < 3; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		hosts = append(hosts, l.Addr().String())
+		if i == 1 {
+			l.Close() // refused from now on
+		} else {
+			t.Cleanup(func() { l.Close() })
+		}
+	}
+	return hosts
+}
+
+func TestQuorum(t *testing.T) {
+	hosts := quorumHosts(t)
+	tests := []struct {
+		quorum int
+		state  State
+		host   string
+	}{
+		{1, Up, hosts[0]},
+		{2, Up, hosts[0]},
+		{3, Down, hosts[1]},
+	}
+	for _, tt := range tests {
+		c := &Checker{Hostports: hosts, Quorum: tt.quorum, SkipInterfaceCheck: true}
+		st := c.Step()
+		if st.State != tt.state || st.Host != tt.host {
+			t.Errorf("Quorum %d: %v for %s, want %v for %s", tt.quorum, st.State, st.Host, tt.state, tt.host)
+		}
+		if tt.state == Down && (!errors.Is(st.Err, ErrRefused) || !strings.Contains(st.Error, "2 of 3 hosts reachable")) {
+			t.Errorf("Quorum %d: error %v, want a refused one counting the reachable hosts", tt.quorum, st.Err)
+		}
+		if len(st.Hosts) != len(hosts) {
+			t.Fatalf("Quorum %d: %d Status.Hosts, want %d", tt.quorum, len(st.Hosts), len(hosts))
+		}
+		for i, h := range st.Hosts {
+			if h.Host != hosts[i] || h.Reachable != (i != 1) || (h.Error == "") != h.Reachable {
+				t.Errorf("Quorum %d: Status.Hosts[%d] = %+v", tt.quorum, i, h)
+			}
+		}
+	}
+}
+
+func TestQuorumProbesConcurrently(t *testing.T) {
+	hosts := []string{"a:1", "b:1", "c:1"}
+	arrived := make(chan struct{})
+	release := make(chan struct{})
+	c := &Checker{
+		Hostports:          hosts,
+		Quorum:             len(hosts),
+		SkipInterfaceCheck: true,
+		Dialer: dialFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			arrived <- struct{}{}
+			<-release // until every host has been dialed
+			a, b := net.Pipe()
+			b.Close()
+			return a, nil
+		}),
+	}
+	done := make(chan Status)
+	go func() { done <- c.Step() }()
+	for range hosts {
+		<-arrived
+	}
+	close(release)
+	if st := <-done; st.State != Up {
+		t.Errorf("%v with every host reachable: %v", st.State, st.Err)
+	}
+}
+
+func TestQuorumValidate(t *testing.T) {
+	for _, c := range []*Checker{
+		{Hostports: []string{"a:1", "b:1"}, Quorum: -1},
+		{Hostports: []string{"a:1", "b:1"}, Quorum: 3},
+		{Hostport: "a", Ports: []int{1, 2}, Quorum: 1},
+	} {
+		if err := c.Validate(); !errors.Is(err, ErrConfig) {
+			t.Errorf("Quorum %d with %d hosts and Ports %v: Validate() = %v", c.Quorum, len(c.hostports()), c.Ports, err)
+		}
+	}
+}
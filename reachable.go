@@ -44,6 +44,12 @@
 package reachable
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
 	"net"
 	"strings"
 	"sync"
@@ -52,131 +58,2152 @@ import (
 
 var (
 	// DefaultInterval is the polling interval when network checks are made.
+	//
+	// Assigning DefaultInterval or DefaultTimeout while any Checker is
+	// running is a data race. Set them before starting Checkers, or use
+	// SetDefaultInterval and SetDefaultTimeout, which are safe at any time.
 	DefaultInterval = time.Minute
 
 	// DefaultTimeout specifies how long the TCP connection attempt should wait
 	// before timing out. This should be adjusted for high-latency connections.
 	DefaultTimeout = time.Second * 3
 
+	// MinInterval is the shortest delay allowed between the scheduled checks
+	// of any Checker, however Interval, jitter and the other scheduling
+	// options work out. Shorter delays are raised to it, so that a tiny
+	// Interval cannot spin a CPU or flood the host, and a Checker started
+	// with an Interval below it writes a warning to its DebugWriter. CheckNow
+	// is not limited. Like DefaultInterval it must be set before starting
+	// Checkers; zero or negative disables the limit.
+	MinInterval = time.Millisecond * 100
+
 	singleton = &Checker{}
 	smu       = &sync.Mutex{}
 	sup       = true
+
+	// defaultMu serializes starting and stopping the default Checker.
+	defaultMu sync.Mutex
 )
 
 // Checker is a reachability checker that notifies calling code when a given
 // host and port is reachable via the network.
 type Checker struct {
 	// Hostport contains the hostname and port to contact to verify
-	// connectivity. If no port is provided, assumes default port 80.
+	// connectivity. If no port is provided, assumes default port 80, also for
+	// an IPv6 address, bare or in brackets ("::1" or "[::1]"). Hostport itself
+	// is never modified.
+	//
+	// Hostport may instead be a URL. With an http or https scheme the probe
+	// is an HTTP request for the URL (see HTTPMethod), which must return a
+	// status below 400 (see AcceptStatus), so that a server accepting
+	// connections but answering 502 is found down; with a tcp scheme
+	// ("tcp://host:port") it is a plain TCP connect, and with a tls scheme
+	// ("tls://host:port", port 443 by default) a TCP connect followed by a
+	// TLS handshake (see TLSConfig). An invalid URL or unsupported scheme
+	// fails every check with an error matching ErrConfig.
 	Hostport string
 
+	// Hostports, if not empty, is used instead of Hostport: the network is
+	// considered reachable if any of them can be reached. Each check tries
+	// them in order until one succeeds, unless Quorum is set.
+	Hostports []string
+
+	// Quorum, if above zero, probes every entry of Hostports concurrently on
+	// each check, so that it takes as long as the slowest host rather than
+	// their sum, and considers the hosts reachable only if at least Quorum
+	// of them are; set it to len(Hostports) to require all of them, e.g. to
+	// watch a set of backends. The per-host results are reported in
+	// Status.Hosts. RotateHosts and ShuffleHosts are ignored.
+	Quorum int
+
+	// Tags is arbitrary metadata about the Checker, such as a tenant, region
+	// or severity, for routing its notifications. It is reported in
+	// Status.Tags, and OnAnyTransition hooks can read it from the Checker
+	// they are passed. It must not be modified while the Checker is running.
+	Tags map[string]string
+
+	// Ports, if not empty, checks each of these ports on the host named by
+	// Hostport (any port in Hostport is ignored) concurrently on every check.
+	// The host is only considered reachable if all of the ports are, and
+	// the per-port results are reported in Status.Ports.
+	Ports []int
+
+	// CaptivePortal replaces the probe with a captive portal check: an HTTP
+	// GET, without following redirects, of a well-known detection URL whose
+	// exact response is known. Any other response means a portal, typically
+	// a Wi-Fi sign-in page, intercepted the request, and the State is
+	// reported as CaptivePortal, unreachable, with an error matching
+	// ErrCaptivePortal, so the app can prompt the user to sign in. The
+	// default URL depends on the platform:
+	//
+	//    android, and any other  http://connectivitycheck.gstatic.com/generate_204 (204, empty)
+	//    darwin, ios             http://captive.apple.com/hotspot-detect.html ("Success" page)
+	//    windows                 http://www.msftconnecttest.com/connecttest.txt ("Microsoft Connect Test")
+	//    linux                   http://nmcheck.gnome.org/check_network_status.txt ("NetworkManager is online")
+	//
+	// CaptivePortalURL overrides the URL. It must return exactly
+	// CaptivePortalResponse with status 200, or if that is empty, status 204
+	// with an empty body.
+	CaptivePortal         bool
+	CaptivePortalURL      string
+	CaptivePortalResponse string
+
+	// TLSConfig, if set, configures the handshake of tls:// and https://
+	// probes. Its ServerName defaults to the URL's host. If nil, the default
+	// configuration is used, which verifies the server's certificate.
+	TLSConfig *tls.Config
+
+	// ExpectALPN, if set, makes tls:// probes fail with an error matching
+	// ErrALPN unless the server negotiates this application protocol, such
+	// as "h2", catching load balancers and proxies that silently drop
+	// HTTP/2. The protocols offered are TLSConfig.NextProtos, or just
+	// ExpectALPN if that is empty. The negotiated protocol is reported in
+	// Status.ALPN whether or not ExpectALPN is set.
+	ExpectALPN string
+
+	// DialHost, if set, is connected to instead of the URL's host by http,
+	// https and tls probes, while the URL's host is still sent as the TLS
+	// server name and HTTP Host header. This checks one virtual host through
+	// a shared front end such as a reverse proxy. If DialHost has no port,
+	// the URL's port is used. HTTP proxies from the environment are not used
+	// with DialHost. HostHeader, if set, overrides the Host header of http
+	// and https probes; TLSConfig.ServerName overrides the server name.
+	// Setting either for other kinds of Hostport fails every check with an
+	// error matching ErrConfig.
+	DialHost   string
+	HostHeader string
+
+	// HTTPMethod is the request method for HTTP probes. If empty, uses HEAD,
+	// which avoids downloading a body. When a HEAD request is answered with
+	// 405 Method Not Allowed it is retried once as a GET, unless
+	// DisableGETFallback is set. Some servers mishandle HEAD in other ways,
+	// e.g. answering 404 or never responding; use "GET" for those.
+	HTTPMethod         string
+	DisableGETFallback bool
+
+	// AcceptStatus, if not empty, lists the response status codes that make
+	// an HTTP probe succeed, such as []int{200, 204} for a health endpoint,
+	// in place of any status below 400. Other codes fail the probe with an
+	// error matching ErrHTTPStatus.
+	AcceptStatus []int
+
+	// NoRedirects makes HTTP probes check a redirect response itself instead
+	// of following it, so that a redirect to a login page is not mistaken
+	// for the service. With the default acceptance any 3xx status then
+	// succeeds; use AcceptStatus to reject it.
+	NoRedirects bool
+
+	// HealthJSON makes http and https probes GET a health endpoint returning
+	// the IETF "application/health+json" format, e.g. {"status": "pass"},
+	// and judge the host by its status instead of the HTTP status code:
+	// "pass" is Up, "warn" is Degraded with an error matching ErrHealthWarn,
+	// and "fail" is Down with an error matching ErrHealthFail. A response
+	// that has no such JSON (up to 64 KiB) fails with ErrHealthResponse, or
+	// ErrHTTPStatus if its status code is 400 or above. The parsed response
+	// is reported in Status.Health. HTTPMethod is ignored.
+	HealthJSON bool
+
+	// RotateHosts probes just one randomly chosen entry of Hostports per
+	// check rather than trying them in order, to spread load across mirrors.
+	RotateHosts bool
+
+	// RotateFailover, with RotateHosts, immediately tries a second randomly
+	// chosen host when the first fails, before reporting the network down.
+	RotateFailover bool
+
+	// ShuffleHosts tries the entries of Hostports in a new random order on
+	// every check, stopping at the first that is reachable, so that no
+	// single provider of a general internet check is always queried first
+	// and the outage of one provider is not mistaken for being offline.
+	// Status.Host reports the entry that confirmed reachability.
+	ShuffleHosts bool
+
+	// Name identifies the Checker in logs, metrics and its Status. If empty,
+	// the Hostport (or the Hostports, comma-separated) is used.
+	Name string
+
 	// Interval to poll for network access. If zero or negative, uses DefaultInterval.
 	Interval time.Duration
 
 	// Notifier is the user-specified callback for reachability notifications.
+	// It may be nil, for a Checker that is only queried with Status.
+	// Assigning it directly once the Checker is started is a data race; use
+	// SetNotifier instead.
 	Notifier func(bool)
 
-	quit chan struct{}
+	// SkipInitialNotify makes the first check after Start establish the
+	// baseline state silently, so that Notifier, NotifierCtx,
+	// ReasonNotifier, notifiers added with AddNotifier and OnDown only fire
+	// on later changes. By default the initial state is notified too.
+	SkipInitialNotify bool
+
+	// AlwaysNotify makes Notifier, NotifierCtx, ReasonNotifier and notifiers
+	// added with AddNotifier fire after every completed check with the
+	// current reachability, rather than only when it changes. This suits
+	// consumers that simply refresh a display each interval. Checks skipped
+	// by ShouldCheck, MaxBytesPerHour or a SuppressWindows window fire
+	// nothing, and MinNotifyInterval still applies. OnDown and the other
+	// transition callbacks are unaffected.
+	AlwaysNotify bool
+
+	// NotifierCtx, if set, is called like Notifier (after it, when both are
+	// set) but with a context that is cancelled as soon as Stop is called, so
+	// that notifier work in progress can be abandoned rather than outlive the
+	// Checker. StopAndWait waits for an in-flight call to return.
+	NotifierCtx func(ctx context.Context, reachable bool)
+
+	// NotifyChan, if set, is sent every notification the Notifier gets, for
+	// receivers that should not hold up the polling loop. The send never
+	// blocks: if the channel is full the notification is dropped, so give
+	// it a buffer and read Status to catch up after falling behind, or use
+	// Updates, which always keeps the latest State. It is sent after the
+	// Notifier, is never closed by the Checker, and must not be changed
+	// while the Checker is running.
+	NotifyChan chan<- bool
+
+	// ReasonNotifier, if set, is called like Notifier (after NotifierCtx,
+	// when that is set) with a short human-readable description of the
+	// outcome of the most recent check, as made by Reason, for logging or
+	// display.
+	ReasonNotifier func(reachable bool, reason string)
+
+	// OnTransition, if set, is called from the polling goroutine whenever the
+	// State changes, including between Up and Degraded which Notifier does
+	// not report.
+	OnTransition func(from, to State)
+
+	// Significant, if set, decides which State changes are worth reporting,
+	// e.g. to ignore churn between Up and Degraded but always report Down.
+	// A change it rejects is not reported to OnTransition, Updates, Events
+	// or OnAnyTransition, and if it changes Reachable the Notifier, OnDown
+	// and OnReachableAgain are not called either: the new state is taken as
+	// notified, as for the baseline of SkipInitialNotify. The change is
+	// still recorded in Status and History. If nil, every change is
+	// reported.
+	Significant func(from, to State) bool
+
+	// OnDown, if set, is called with a coarse Cause each time the host is
+	// notified as unreachable, after the Notifier. It is a lightweight way
+	// to tell e.g. a timeout from a refused connection.
+	OnDown func(cause Cause)
+
+	// OnFirstReachable, if set, is called once the first time the host is
+	// found reachable after Start, after the Notifier. It is useful for
+	// one-time work such as an initial sync; later recoveries are reported
+	// to OnReachableAgain.
+	OnFirstReachable func()
+
+	// OnReachableAgain, if set, is called, after the Notifier, whenever a
+	// host that was notified as unreachable becomes reachable again, but not
+	// for the initial state. It is the place for reconnect logic, e.g. for
+	// a websocket or database connection, with OnFirstReachable handling
+	// startup.
+	OnReachableAgain func()
+
+	// OnAddressChange, if set, is called when a successful probe reached a
+	// different remote address than the previous successful one, as from a
+	// DNS failover, independent of reachability. The current address is
+	// Status.Addr. It is only meaningful when each probe resolves the host
+	// again: an IP address Hostport, a ConnFactory or PingFunc probe, or a
+	// connection kept open by ReuseConn never changes address.
+	OnAddressChange func(old, new net.Addr)
+
+	// OnHostNotFound, if set, is called with the error when a check fails
+	// because the host name does not exist, matching ErrHostNotFound, after
+	// a check that did not. Retrying such a name is unlikely to help, so
+	// this is the place to surface a misconfiguration loudly. Some networks,
+	// e.g. behind a captive portal, answer NXDOMAIN for every name, so the
+	// error is not always permanent. HostNotFoundInterval, if positive,
+	// replaces the interval, and any backoff, while checks keep failing this
+	// way, so that a bad name can be retried rarely.
+	OnHostNotFound       func(err error)
+	HostNotFoundInterval time.Duration
+
+	// MinNotifyInterval, if positive, rate-limits the Notifier,
+	// NotifierCtx, ReasonNotifier and AddNotifier callbacks: after a
+	// notification, further changes within this interval are held back, and
+	// once it has passed the latest reachability is delivered if it differs
+	// from the last one delivered. A flapping host thus produces at most one
+	// notification per interval, and the notifiers always end up with the
+	// true state, though intermediate states may be skipped. Other
+	// callbacks, such as OnDown and OnTransition, are not limited. The
+	// held-back change is delivered from the polling goroutine like any
+	// other; with Step, which has none, it is delivered by the first Step
+	// after it is due.
+	//
+	// MaxNotifyDelay, if positive, bounds how long a change can be held
+	// back: the latest state is delivered no later than MaxNotifyDelay after
+	// the first change that was held back, even if that is sooner than
+	// MinNotifyInterval allows. With both set, notifications are normally at
+	// least MinNotifyInterval apart, but a host that keeps flapping is
+	// reported every MaxNotifyDelay when that is shorter, and a sustained
+	// change is always reported within the shorter of the two. It has no
+	// effect without MinNotifyInterval.
+	MinNotifyInterval time.Duration
+	MaxNotifyDelay    time.Duration
+
+	// DependsOn, if set, is a Checker for a lower layer of connectivity that
+	// this host is only reachable through, e.g. a VPN or gateway. While
+	// DependsOn finds its host Down or behind a CaptivePortal, this Checker
+	// reports Down with ErrDependencyDown without probing, and as soon as
+	// DependsOn finds it reachable again this Checker checks at once. While
+	// DependsOn is Unknown, because it has not completed a check or is not
+	// running, this Checker probes as if it had no dependency. Chains may be
+	// any length, but a cycle is a configuration error reported as
+	// ErrConfig on every check.
+	DependsOn *Checker
+
+	// Dispatch, if set, is handed every callback invocation (Notifier,
+	// NotifierCtx, OnTransition, etc.) instead of the callback being run inline
+	// on the polling goroutine. This allows notifications to be delivered on a
+	// specific thread, e.g. by posting to a GUI event loop. Dispatch itself is
+	// called from the polling goroutine, in order.
+	Dispatch func(func())
+
+	// DegradedThreshold, if positive, reports a reachable host as Degraded
+	// while its smoothed probe latency exceeds the threshold. Notifier still
+	// treats a Degraded host as reachable.
+	DegradedThreshold time.Duration
+
+	// MaxLatency, if positive, is a hard cap on probe latency: a probe that
+	// succeeds but takes longer is reported as a failure, with an error
+	// matching ErrTooSlow, since such a slow service is unusable. This is
+	// stricter than DegradedThreshold. The cap applies to each probe's own
+	// latency rather than the smoothed Status.Latency, and like other failed
+	// probes a capped one does not feed the smoothed average.
+	MaxLatency time.Duration
+
+	// LatencySmoothing is the weight given to each new latency sample in the
+	// exponentially weighted moving average compared against
+	// DegradedThreshold. Values closer to 0 smooth more heavily. If not
+	// within (0,1], uses DefaultLatencySmoothing.
+	LatencySmoothing float64
+
+	// SuppressWindows are recurring times of day, such as a nightly
+	// maintenance window, during which notifications are withheld. Checks
+	// still run, and any change is notified once the window closes.
+	SuppressWindows []TimeWindow
+
+	// SkipProbesInWindows skips probes entirely, rather than only withholding
+	// notifications, while inside one of the SuppressWindows.
+	SkipProbesInWindows bool
+
+	// ConnFactory, if set, is used instead of dialing Hostport. A connection
+	// returned without error means the host is reachable; the Checker closes
+	// it immediately. The context expires after the probe timeout.
+	ConnFactory func(ctx context.Context) (net.Conn, error)
+
+	// Dialer, if set, opens every connection the probes make, in place of a
+	// net.Dialer, including those of HTTP and tls:// probes and through
+	// ConnectProxy. It can reach a service over a Unix socket whatever
+	// address it is asked for, or present a client certificate with a
+	// *tls.Dialer. Unlike ConnFactory it keeps the probe otherwise
+	// unchanged. LocalPortMin, LocalPortMax and Interfaces do not apply to
+	// its connections.
+	Dialer Dialer
+
+	// PingFunc, if set, is used instead of any network probe: the host is
+	// reachable when it returns nil. It lets an app check liveness over its
+	// existing connection pool, e.g. with db.PingContext, rather than
+	// dialing again. The context expires after the probe timeout.
+	PingFunc func(ctx context.Context) error
+
+	// SkipInterfaceCheck disables the check for an active non-loopback network
+	// interface before each probe. This is useful with a ConnFactory whose
+	// transport does not depend on local interfaces. It is the same as
+	// setting InterfaceGate to InterfaceGateOff.
+	SkipInterfaceCheck bool
+
+	// InterfaceGate is the policy for the interface check before each probe.
+	// The default, InterfaceGateBlock, reports the host unreachable without
+	// probing when no interface is up. InterfaceGateAdvisory still checks
+	// the interfaces and reports the result in Status.InterfaceUp and
+	// Status.Interface, but always probes, for setups such as VPN-only
+	// routes where the check misjudges connectivity. InterfaceGateOff skips
+	// the check.
+	InterfaceGate InterfaceGate
+
+	// InterfaceCacheTTL, if positive, reuses the result of the interface
+	// check for this long instead of listing the interfaces again on every
+	// check, which reduces overhead with sub-second intervals. A longer TTL
+	// delays noticing a link that went down or came up. By default the
+	// interfaces are listed on every check.
+	InterfaceCacheTTL time.Duration
+
+	// IncludeLoopback lets a loopback interface satisfy the interface check,
+	// which is otherwise skipped as it does not help reach remote hosts. Set
+	// this when monitoring services on localhost.
+	IncludeLoopback bool
+
+	// IsMetered, if set, classifies the interface that satisfied the
+	// interface check, reported in Status.Metered, so that apps can save
+	// data on cellular or other metered links. Go does not expose the link
+	// type portably, but the interface's name and flags are usually enough
+	// for a heuristic, e.g. names starting with "wwan" or "rmnet".
+	IsMetered func(net.Interface) bool
+
+	// Interfaces, if set, turns the Checker into a multi-WAN monitor: each
+	// check runs the probe once through every named interface concurrently,
+	// with connections sourced from that interface's address, and reports
+	// the per-interface results in Status.Uplinks. The host is reachable if
+	// any uplink is. Sourcing from an address selects the uplink on most
+	// multi-WAN setups, but routing may still need a policy rule per source
+	// address. ConnFactory probes are not affected, and ReuseConn does not
+	// apply. OnProbeStart, OnProbeEnd and OnProbeResult are called
+	// concurrently, once per uplink.
+	Interfaces []string
+
+	// InterfaceOnly disables probing entirely: the host is reported
+	// reachable whenever an interface that is up has a routable unicast
+	// address, and nothing is ever dialed. This trades accuracy for zero
+	// network cost on very low-power devices. Hostport and all probe
+	// settings are ignored, as are SkipInterfaceCheck and InterfaceGate.
+	InterfaceOnly bool
+
+	// FailOpen sets the assumed state when a check cannot tell whether the
+	// host is reachable: with FailOpen it is assumed reachable ("fail open"),
+	// and otherwise unreachable ("fail closed"), which is the default. The
+	// policy governs exactly two conditions: listing the network interfaces
+	// fails, with an error matching ErrInterfaceList, and the probe cannot be
+	// made because of its configuration, with an error matching ErrConfig.
+	// In both cases Status.Err still reports the error. A host found to have
+	// no interface up, or failing its probe, is always unreachable.
+	FailOpen bool
+
+	// MaxBytesPerHour, if positive, is a data budget for checks, for devices
+	// on metered links. Checks whose estimated data use would exceed what is
+	// left of the budget are skipped, leaving the last known state in place,
+	// with CheckNow included. The budget allows bursts of up to an hour's
+	// worth and refills continuously. Use is estimated from the probe kind,
+	// not measured: about 420 bytes for a TCP connect and close, 200 for a
+	// DNS lookup of a host name, 6000 for a TLS handshake, and 800 for the
+	// headers of an HTTP request, plus any Send, Expect, LargeProbeSize or
+	// GET body (up to 4096 bytes) payload. Only the first of Hostports is
+	// counted, retries and redirects are not, and PingFunc probes count as
+	// nothing, so keep a margin. Stats reports the estimated use and the
+	// skipped checks.
+	MaxBytesPerHour int64
+
+	// ShouldCheck, if set, is called before each probe. Returning false skips
+	// that cycle entirely, leaving the current state unchanged and notifying
+	// nothing. This can be used to slow down or pause checks on battery
+	// power, while backgrounded, and so on.
+	ShouldCheck func() bool
+
+	// Enabled, if set, is an operational off-switch called before each
+	// probe, like ShouldCheck but also before those requested by CheckNow
+	// and EnsureReachable. While it returns false no probes are made and the
+	// last state is held; with AlwaysNotify the notifiers still get that
+	// state every interval, as a heartbeat. Keep it cheap, e.g. reading a
+	// flag that ops can flip fleet-wide, or checking for a sentinel file:
+	//
+	//    c.Enabled = func() bool {
+	//        _, err := os.Stat("/etc/myapp/disable-monitoring")
+	//        return os.IsNotExist(err)
+	//    }
+	//
+	// Watching the file and caching the result in an atomic flag avoids a
+	// stat per check.
+	Enabled func() bool
+
+	// ShouldCheckInterval, if positive, is how often ShouldCheck and
+	// Enabled are asked again while either returns false, in place of the
+	// usual interval. The host is probed as soon as they return true, so
+	// that e.g. an app coming back to the foreground sees a fresh state
+	// within this interval. It should be short; they must then be cheap.
+	ShouldCheckInterval time.Duration
+
+	// Freshness is how recent the last check must be for EnsureReachable to
+	// trust it without checking again. If zero or negative, uses
+	// DefaultFreshness.
+	Freshness time.Duration
+
+	// ResolveTimeout and ConnectTimeout, when either is set, split the probe
+	// into a DNS lookup followed by a dial of the resolved addresses, each
+	// limited by its own timeout. This helps on networks where DNS is slow
+	// or flaky but routes are stable. An unset value falls back to
+	// DefaultTimeout. When both are unset the probe is a single dial limited
+	// by DefaultTimeout as a whole.
+	ResolveTimeout time.Duration
+	ConnectTimeout time.Duration
+
+	// CheckBudget, if positive, is a single time limit for each probe as a
+	// whole, split automatically across its phases: 30% for the DNS lookup,
+	// 40% for connecting to the resolved addresses, and the remainder, along
+	// with any time the earlier phases did not use, for handshakes and the
+	// exchange. An explicit ResolveTimeout or ConnectTimeout replaces that
+	// phase's share, and an explicit Timeout replaces the overall limit.
+	CheckBudget time.Duration
+
+	// Network restricts probes to one address family: "tcp4" resolves only A
+	// records and dials over IPv4, "tcp6" only AAAA records over IPv6. This
+	// allows separate IPv4 and IPv6 monitors for the same host. If empty,
+	// uses "tcp", which accepts either family.
+	Network string
+
+	// HappyEyeballs makes plain TCP probes resolve the host and race its
+	// IPv6 and IPv4 addresses, as in RFC 8305: IPv6 is dialed first, and
+	// IPv4 after HappyEyeballsDelay or as soon as IPv6 fails. The host is
+	// reported reachable as soon as either family connects, and the other
+	// attempt is cancelled. The winning family and address are reported in
+	// Status.Family and Status.Addr, which helps diagnose a slow IPv6 path.
+	// It is only used when Network is "tcp" and ReuseConn does not apply.
+	HappyEyeballs bool
+
+	// RetryFreshDNS retries a failed probe once with a fresh DNS lookup,
+	// made directly against the configured name servers to bypass any OS
+	// resolver cache, before reporting the host down. This helps with
+	// DNS-based failover, where a stale cached address keeps failing. The
+	// newly resolved addresses are reported in Status.Resolved. It is off by
+	// default to avoid extra DNS load.
+	RetryFreshDNS bool
+
+	// Resolver is used for DNS lookups when they are made separately from the
+	// dial. If nil, net.DefaultResolver is used.
+	Resolver *net.Resolver
+
+	// ExpectResolvesTo, if set, lists the IP addresses and CIDR blocks the
+	// host must resolve to, such as "10.0.0.0/8" for an internal name. A
+	// probe finding any resolved address outside them fails with
+	// ErrUnexpectedAddress, reported in Status.UnexpectedAddr, without
+	// connecting, which catches DNS hijacking and split-horizon mistakes
+	// that would otherwise look reachable. DNS is then resolved as a
+	// separate step, as with ResolveTimeout. It does not apply through
+	// ConnectProxy, which resolves the host itself. If empty, any address
+	// is accepted.
+	ExpectResolvesTo []string
+
+	// Send and Expect opt in to a stronger check than a bare TCP handshake,
+	// to detect half-open paths that connect but then drop data. After
+	// connecting, Send is written and a response must arrive before the
+	// probe timeout. If Expect is set the response must begin with it,
+	// otherwise any single byte will do.
+	Send   []byte
+	Expect []byte
+
+	// LargeProbeSize, if positive, follows each successful connect by
+	// writing a payload of this many bytes and requiring the host to echo it
+	// back before the probe timeout. If small probes succeed but the large
+	// one fails, the host is reported Degraded with an error matching
+	// ErrLargeProbe, as tunnels that black-hole large packets let tiny
+	// probes through while real traffic fails. The host must run an echo
+	// service (e.g. RFC 862 on port 7).
+	//
+	// Detection over TCP is limited: TCP sizes segments from the negotiated
+	// MSS, so routers that clamp MSS hide a smaller path MTU, and the
+	// kernel's own path MTU discovery may recover before the probe fails.
+	// The check catches paths where large segments are silently dropped.
+	LargeProbeSize int
+
+	// Inspect, if set, is called with each probe connection after it is
+	// established and any Send/Expect exchange is done, and before it is
+	// closed, for arbitrary validation such as checking TLS state or peer
+	// certificates. Returning an error marks the host unreachable. The conn
+	// must not be used after Inspect returns. It is called for TCP and
+	// ConnFactory probes, not HTTP ones.
+	Inspect func(net.Conn) error
+
+	// ConnectProxy, if set, is the URL of an HTTP proxy,
+	// "http://[user:password@]host:port" or the same with https, that all
+	// probes are tunnelled through, for networks that only allow TCP out
+	// through a proxy. TCP and tls:// probes send it a CONNECT request for
+	// the host and port, and are reachable when it answers 200 and the
+	// rest of the probe (Send, Expect, TLS handshake, etc.) succeeds over
+	// the tunnel; any other answer fails the check with an error matching
+	// ErrProxy. The CONNECT exchange is bounded by the probe timeout. HTTP
+	// probes use it as their proxy instead of the environment's, which
+	// makes DialHost ineffective for them. Any user and password in the URL
+	// are sent with Basic authentication. ReuseConn and HappyEyeballs do
+	// not apply, and ResolveTimeout and Network are ignored because the
+	// proxy resolves the host.
+	ConnectProxy string
+
+	// ProxyProtocol, if set to 1 or 2, makes TCP probes send a PROXY
+	// protocol header of that version (the v1 text line or the v2 binary
+	// form) right after connecting, before any Send payload. Load balancers
+	// such as HAProxy or ELB with proxy-protocol enabled reset connections
+	// that do not start with one. The header carries the probe connection's
+	// own addresses. It is off by default.
+	ProxyProtocol int
+
+	// ReuseConn keeps each probe connection open and, on later checks,
+	// only verifies that it has not been closed or reset, instead of making
+	// a new TCP handshake every time. This saves battery and latency on
+	// mobile networks. Held connections use TCP keepalives at Interval so
+	// that a dead path is still noticed, and are replaced with a fresh dial
+	// once older than ReuseMaxAge (default DefaultReuseMaxAge). It applies
+	// to plain TCP probes only; Send/Expect, LargeProbeSize, Inspect, URL
+	// and ConnFactory probes always dial fresh.
+	//
+	// TCP Fast Open is not used: a probe sends no data, and with Fast Open
+	// a connect without data does not reach the network at all.
+	ReuseConn   bool
+	ReuseMaxAge time.Duration
+
+	// LocalPortMin and LocalPortMax, when set, make TCP probes connect from
+	// a local port within this range, cycling through it on successive
+	// probes and skipping ports that are already in use. This is needed
+	// behind some firewalled gateways and industrial NAT setups that only
+	// accept connections sourced from specific ports. By default the
+	// operating system picks an ephemeral port.
+	LocalPortMin int
+	LocalPortMax int
+
+	// RefusedIsReachable treats a refused connection (a TCP reset) as proof
+	// that the network path to the host works even though the service is
+	// down. Such checks are notified as reachable, with Status.ServiceDown
+	// set and Status.Err matching ErrRefused. This helps decide between
+	// retrying against the service and going fully offline.
+	RefusedIsReachable bool
+
+	// ReachableOnErrors lists probe errors that still mean the host is
+	// reachable, such as a service that rejects plain TCP probes in an
+	// expected way. A failed probe whose error matches any of these by
+	// errors.Is, or for which ReachableOnError returns true, is treated as
+	// successful; Status.Err still reports the error. By default any error
+	// means unreachable.
+	ReachableOnErrors []error
+
+	// ReachableOnError, if not nil, is a predicate complementing
+	// ReachableOnErrors. It is passed the classified probe error, which can
+	// be inspected with errors.Is and errors.As.
+	ReachableOnError func(error) bool
+
+	// ConfirmUpAfter, if positive, delays reporting a recovery: the first
+	// successful check after the host was notified as unreachable is not
+	// reported, but schedules a confirmation check this long afterwards, and
+	// the host is only reported reachable if that one succeeds too. This
+	// avoids premature recovery events from a single lucky probe on an
+	// unstable link. The initial state after Start is reported without
+	// confirmation.
+	ConfirmUpAfter time.Duration
+
+	// StickyDuration, if positive, keeps the host reachable for up to this
+	// long after its last successful check, even if checks fail in the
+	// meantime. The host is only reported down once the window has elapsed
+	// and checks are still failing, which smooths over brief outages.
+	// Failed checks are still counted in Stats and ConsecutiveCount.
+	StickyDuration time.Duration
+
+	// NoRouteImmediate reports a probe failing with ErrNoRoute as down at
+	// once, without StickyDuration holding the host up first, since the
+	// operating system already knows the network is gone.
+	NoRouteImmediate bool
+
+	// BaseContext, if not nil, returns the parent context for each probe, for
+	// example one carrying tracing values so the dialer and HTTP client can
+	// attach spans. The probe's timeout is applied to a context derived from
+	// it. If nil, or if it returns nil, context.Background is used.
+	BaseContext func() context.Context
+
+	// Timeout is a hard deadline for each probe as a whole, including DNS
+	// resolution and any handshakes. If zero or negative, uses CheckBudget if
+	// set, or else DefaultTimeout, or the sum of ResolveTimeout and
+	// ConnectTimeout when either is set.
+	Timeout time.Duration
+
+	// ProbeWatchdog, if positive, guards against a probe that ignores its
+	// context, such as a buggy PingFunc or ConnFactory: each probe then
+	// runs on a goroutine of its own, and one that has not returned
+	// ProbeWatchdog past its deadline is abandoned and the check fails with
+	// ErrProbeStuck, so that checking goes on. An abandoned probe runs on
+	// until it returns, possibly still calling OnProbeStart, OnProbeEnd and
+	// OnProbeResult, but its result is thrown away. Zero, the default, runs
+	// each probe on the polling goroutine, with no goroutine or timer per
+	// check.
+	ProbeWatchdog time.Duration
+
+	// OnProbeStart and OnProbeEnd, if set, are called around each individual
+	// probe, so checks can be measured and correlated without a metrics
+	// integration. With several Hostports each host probed is reported
+	// separately, with Ports each port, and with Interfaces each uplink.
+	// With Ports, Quorum or Interfaces the calls are made concurrently from
+	// a goroutine per probe, so the hooks must then be safe for concurrent
+	// use. host is the entry probed, or the Checker's name for a ConnFactory
+	// or PingFunc. OnProbeEnd is passed the probe's own outcome, before
+	// settings such as RefusedIsReachable are applied. Both are called on
+	// the probing goroutine and delay the check while they run.
+	//
+	// OnProbeResult, if set, is called after OnProbeEnd with the same
+	// outcome and the address that was dialed and reached, for metrics per
+	// IP address of anycast or round-robin hosts.
+	OnProbeStart  func(host string)
+	OnProbeEnd    func(host string, reachable bool, latency time.Duration, err error)
+	OnProbeResult func(ProbeResult)
+
+	// OnTrace, if set, is called after every completed check with its full
+	// detail, from the interface check through DNS, every dial attempt and
+	// the phase timings to the final outcome, for diagnosing connectivity
+	// problems on other machines. Recording every dial attempt has a cost,
+	// which is only paid while OnTrace is set.
+	OnTrace func(Trace)
+
+	// RunFor, if positive, stops the Checker automatically once this long
+	// has passed since Start, for monitoring that is only needed during a
+	// time-bounded task. OnExpire, if set, is called after it has stopped.
+	// Stop may still be called earlier, in which case OnExpire is not
+	// called. By default the Checker runs until stopped.
+	RunFor   time.Duration
+	OnExpire func()
+
+	// MaxFailures, if positive, makes the Checker give up and stop once this
+	// many consecutive checks have failed, and GiveUpOnHostNotFound makes it
+	// give up as soon as a check fails with ErrHostNotFound. These are the
+	// only conditions under which it gives up. OnGiveUp, if set, is then
+	// called exactly once, from the polling goroutine after the Checker has
+	// stopped, with the error that made it give up: the last check's,
+	// wrapped with the failure count for MaxFailures, so that it still
+	// matches the package's Err values. A Checker stopped by Stop, StartOnce
+	// or RunFor never calls OnGiveUp. With Step there is no polling to stop:
+	// OnGiveUp is still called once, and later Steps go on checking.
+	MaxFailures          int
+	GiveUpOnHostNotFound bool
+	OnGiveUp             func(reason error)
+
+	// CheckOnResume checks immediately when the system resumes from suspend,
+	// so that a laptop quickly learns its connectivity after waking instead
+	// of waiting for the next interval, which matters most with long
+	// intervals. A resume is noticed within a few seconds. It is supported on
+	// Linux and macOS, as reported by ResumeSupported, and ignored elsewhere.
+	CheckOnResume bool
+
+	// FlapHistory is how many recent State transitions are kept for
+	// FlapCount and History, DefaultFlapHistory if zero. A negative value
+	// keeps none, and FlapCount always returns 0. The history is allocated
+	// once at this size, at 64 bytes per entry plus the text of any error,
+	// and is the only per-Checker buffer that depends on how long the
+	// Checker has run: Stats and the latency average are fixed-size
+	// counters.
+	FlapHistory int
+
+	// ConfidenceWindow is how many recent check results Confidence is
+	// computed from, DefaultConfidenceWindow if zero or negative.
+	ConfidenceWindow int
+
+	// TrendWindow is how many recent probe latencies LatencyTrend is
+	// computed from, DefaultTrendWindow if zero or negative.
+	TrendWindow int
+
+	// OnDegrading, if set, is called with the slope when LatencyTrend rises
+	// above DegradingSlope, in seconds per check, for warning of a
+	// deteriorating link before it fails outright. It is called once as the
+	// trend crosses the threshold, and again only after it has fallen back
+	// to or below it. It is not called unless DegradingSlope is positive.
+	OnDegrading    func(slope float64)
+	DegradingSlope float64
+
+	// Clock, if set, replaces time.Now as the source of the current time for
+	// the state machine: Status timestamps, StickyDuration, Freshness,
+	// FlapCount, SuppressWindows and OnSustainedOutage. Timers, probe
+	// deadlines and latencies still use the system clock. It exists so that
+	// tests driving the Checker with Step are deterministic.
+	Clock func() time.Time
+
+	// DebugWriter, if set, receives one line of text per check with its
+	// time, Name, host, result, latency and error, for ad-hoc troubleshooting.
+	// Writes are serialized across all Checkers, so a single writer such as
+	// os.Stderr can be shared without lines interleaving.
+	DebugWriter io.Writer
+
+	// MaxInterval, if greater than Interval, enables exponential backoff
+	// while the host is down: the interval doubles after each consecutive
+	// failure, up to MaxInterval, and returns to Interval once the host is
+	// reachable again.
+	MaxInterval time.Duration
+
+	// BackoffMin, if positive and below Interval, polls faster while the host
+	// is down, so that recovery is noticed sooner: the first retry after a
+	// failure comes BackoffMin later, and the delay doubles after each
+	// further failure up to Interval, or on to MaxInterval if that is
+	// greater. Once the host is reachable again checks return to Interval.
+	// BackoffFunc and NextInterval take precedence.
+	BackoffMin time.Duration
+
+	// FastStartProbes, if positive, runs the first that many checks after
+	// Start FastStartInterval apart (DefaultFastStartInterval if zero),
+	// instead of Interval apart, to establish the initial state quickly
+	// before settling into normal polling. The
+	// phase ends after that many completed checks, whatever their results.
+	// During it the fast-start interval replaces HostNotFoundInterval,
+	// NextInterval and backoff, but a ConfirmUpAfter confirmation still
+	// takes precedence, and jitter is still applied.
+	FastStartProbes   int
+	FastStartInterval time.Duration
+
+	// BackoffFunc, if set, replaces the built-in exponential backoff with a
+	// custom strategy, such as Fibonacci, decorrelated jitter or a fixed
+	// sequence. It returns the delay before the next check while the host is
+	// Down, given the number of consecutive failed checks so far, starting
+	// at 1 after the first failure, and Interval as the base. The count
+	// resets once a check succeeds. A result of zero or less uses Interval.
+	// MaxInterval does not cap the result, but still sets the threshold for
+	// OnSustainedOutage. NextInterval takes precedence when both are set.
+	BackoffFunc func(attempt int, base time.Duration) time.Duration
+
+	// OnSustainedOutage, if set, is called once per outage when backoff
+	// reaches MaxInterval, with the time of the first failure. The host has
+	// then likely been down for a while, making this a hook for escalation
+	// distinct from the initial down notification. It is re-armed when the
+	// host becomes reachable.
+	OnSustainedOutage func(since time.Time)
+
+	// DownReminderInterval, if positive, calls OnDownReminder repeatedly
+	// while the host stays notified as unreachable: at the first check at
+	// least this long after the down notification, and again after each
+	// further interval, with the time of the down notification. Reminders
+	// stop once the host is reachable again. They are separate from the
+	// Notifier, which is still only called on changes, and are withheld
+	// during SuppressWindows.
+	DownReminderInterval time.Duration
+	OnDownReminder       func(since time.Time)
+
+	// NextInterval, if set, is called after each cycle with the current State
+	// to choose the delay before the next check, enabling adaptive polling
+	// such as checking faster right after a change. Returning zero or a
+	// negative duration uses Interval. NextInterval replaces the backoff
+	// rules; jitter is still applied.
+	NextInterval func(state State) time.Duration
+
+	// Pool, if set, runs this Checker's checks on the Pool's shared worker
+	// goroutines instead of a goroutine of its own. See Pool.
+	Pool *Pool
+
+	// AlignToClock, if set, schedules each check on the next multiple of
+	// its delay since the zero Time, so that with an Interval of a minute
+	// checks run every minute on the minute and line up with other systems
+	// doing the same; otherwise checks run a delay apart counted from
+	// Start. It applies to the first check and to the delays chosen by
+	// Interval, fast start, HostNotFoundInterval, NextInterval and backoff,
+	// but not to ConfirmUpAfter, ShouldCheckInterval or JitterDecorrelated
+	// delays. Jitter is applied after aligning. Boundaries are computed in
+	// UTC, so a daylight-saving change does not move them, and a 24h
+	// Interval aligns to midnight UTC rather than local midnight. A change
+	// of the system clock shifts the check already scheduled, which is
+	// timed on the monotonic clock, but the check after it is aligned again.
+	AlignToClock bool
+
+	// Jitter, if positive, adds a random delay of up to Jitter to each polling
+	// interval so that many Checkers started together do not probe in lockstep.
+	Jitter time.Duration
+
+	// JitterStrategy selects how intervals, including backoff, are
+	// randomized: JitterAdditive (the default) adds up to Jitter, while
+	// JitterEqual, JitterFull and JitterDecorrelated follow the strategies
+	// of the same names popularized for retry backoff, spreading load more
+	// evenly, and ignore Jitter except as noted. The random values come
+	// from Rand, so a seeded Rand makes the schedule reproducible. The
+	// chosen time of the next check is reported by NextCheck.
+	JitterStrategy JitterStrategy
+
+	// Rand is the source of randomness for all randomized timing decisions.
+	// If nil, a package-global source is used. A *rand.Rand is not safe for
+	// concurrent use, so a Rand shared between Checkers must be built on a
+	// source that does its own locking.
+	Rand *rand.Rand
+
+	// currentStatus is the last notified reachability: -1 before the
+	// first notification, otherwise 0 or 1. Only used by the run goroutine.
+	currentStatus int
+
+	// restored is set by RestoreState, so that the next Start carries the
+	// restored reachability over instead of treating it as unknown.
+	restored bool
+
+	// reachedOnce is set once the host has been reachable since Start. Only
+	// used by the run goroutine.
+	reachedOnce bool
+
+	// lastAddr is the remote address of the last successful probe, for
+	// OnAddressChange. Only used by the run goroutine.
+	lastAddr net.Addr
+
+	// hostNotFound is set while checks fail with ErrHostNotFound. Only used
+	// by the run goroutine.
+	hostNotFound bool
+
+	// budget is the remaining MaxBytesPerHour allowance as of budgetAt,
+	// which is zero until the first check. Only used by the run goroutine.
+	budget   float64
+	budgetAt time.Time
+
+	// downSince is when the host was notified as unreachable, and
+	// lastReminder when OnDownReminder was last due. Only used by the run
+	// goroutine.
+	downSince    time.Time
+	lastReminder time.Time
+
+	// ifaceScanned is when the interfaces were last listed with
+	// InterfaceCacheTTL set, and ifaceCached and ifaceErr the result. Only
+	// used by the checking goroutine.
+	ifaceScanned time.Time
+	ifaceCached  *net.Interface
+	ifaceErr     error
+
+	// confirming is set while a ConfirmUpAfter confirmation probe is
+	// pending. Only used by the checking goroutine.
+	confirming bool
+
+	// gated is set while ShouldCheck is returning false. Only used by the
+	// checking goroutine.
+	gated bool
+
+	// gaveUp is set once the Checker has decided to give up. Only used by
+	// the checking goroutine.
+	gaveUp bool
+
+	// configErr is the result of Validate when the Checker was started.
+	// Only used by the checking goroutine.
+	configErr error
+
+	// stepping is set once Step has initialized the per-run state.
+	stepping bool
+
+	// once is set by StartOnce. It is written before the run goroutine
+	// starts and only read by it afterwards.
+	once bool
+
+	// outageStart is the first failure of the current run of failures, and
+	// outageSignalled is set once OnSustainedOutage has fired for it. Only
+	// used by the run goroutine.
+	outageStart     time.Time
+	outageSignalled bool
+
+	// degrading is set once OnDegrading has fired for the current upward
+	// trend. Only used by the run goroutine.
+	degrading bool
+
+	// quit and now signal the run goroutine, ctx is done once the run is
+	// stopped, and cancel stops it. They are made by start once any
+	// previous run has exited, and handed to the goroutines of the run
+	// rather than read by them. Guarded by mu.
+	quit   chan struct{}
+	now    chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	status Status
+
+	// notifyMu is held while delivering a change to the notifiers, so that
+	// AddNotifierWithCurrent can be ordered with it.
+	notifyMu sync.Mutex
+
+	// delivered is the reachability last delivered to the notifiers, -1 if
+	// none, and deliveredAt when, for MinNotifyInterval. trailingAt is when
+	// the change held back in pending is due for a trailing delivery, or
+	// zero if none is. Guarded by mu.
+	delivered   int
+	deliveredAt time.Time
+	pending     bool
+	trailingAt  time.Time
+
+	// nextCheck is when the next check is scheduled. Guarded by mu.
+	nextCheck time.Time
+
+	// lastDelay is the previous JitterDecorrelated delay, or zero. Only used
+	// by the run goroutine.
+	lastDelay time.Duration
+
+	// wg tracks the goroutines started by Start, for StopAndWait.
+	wg sync.WaitGroup
+
+	// notifiers are added with AddNotifier. The slice is replaced, never
+	// modified in place, so it can be iterated outside the lock. Guarded by
+	// mu.
+	notifiers []notifierEntry
+	nextID    int
+
+	// held holds connections kept open by ReuseConn. Guarded by mu.
+	held map[string]*heldConn
+
+	// running is set between Start and Stop, and subs are the channels
+	// returned by subscribe. stopping is set once Stop has been called.
+	// Guarded by mu.
+	running  bool
+	stopping bool
+	subs     map[chan State]struct{}
+
+	// probeCancel cancels the probe in progress, if any, for Stop. Guarded
+	// by mu.
+	probeCancel context.CancelFunc
+
+	// paused is set by Pause. Guarded by mu.
+	paused bool
+
+	// startedAt is when Start was called, and firstCheckAt and
+	// firstReachableAt when the first check completed and first found the
+	// host reachable since, on the system clock. Guarded by mu.
+	startedAt        time.Time
+	firstCheckAt     time.Time
+	firstReachableAt time.Time
+
+	// events are the channels returned by Events. Guarded by mu.
+	events []chan Event
+
+	// localPort is the last port used from the local port range. Guarded by
+	// mu.
+	localPort int
+
+	// transitions are the most recent State transitions, for FlapCount and
+	// History. Guarded by mu.
+	transitions []Transition
+
+	// recent are the most recent probe results, for Confidence, and
+	// latencies those of successful probes, for LatencyTrend. Guarded by
+	// mu.
+	recent    []bool
+	latencies []time.Duration
+
+	// checked is made by the first goroutine to wait for a result, and
+	// closed and cleared after the next check, waking all waiters. Made
+	// lazily so that checks nobody waits for allocate nothing. Guarded by mu.
+	checked chan struct{}
+	stats   Stats
+}
+
+// StartContext is like Start, but the Checker also stops when ctx is done, as
+// if Stop had been called, for services that shut down by cancelling a
+// context. A probe in progress is abandoned at once, by Stop too, rather
+// than waiting out its Timeout.
+func (c *Checker) StartContext(ctx context.Context) {
+	stopped := c.start(false).Done()
+	c.goroutine(func() {
+		select {
+		case <-ctx.Done():
+			c.stop()
+		case <-stopped:
+		}
+	})
 }
 
-// Start begins Checker polling in a background goroutine.
+// Start begins Checker polling in a background goroutine. The first check is
+// made at once rather than after Interval, unless AlignToClock is set, so the
+// Notifier soon reports the real initial state; Start does not wait for it.
+// See WaitFirstCheck to block until it completes. Start does nothing if the
+// Checker is already running. After Stop it first waits for the goroutines
+// of the previous run to exit, as StopAndWait does, so it must not be called
+// from a callback of the same Checker once that has called Stop.
 func (c *Checker) Start() {
-	c.quit = make(chan struct{})
-	go c.run()
+	c.start(false)
 }
 
-// Stop tells the background goroutine to stop checking.
+// StartOnce is like Start for one-shot startup gating: the Checker polls until
+// the host is first found reachable, notifies as usual, and then stops itself.
+// Use EnsureReachable or a notifier to wait for that. Calling Stop is only
+// needed to give up before the host was reached; once the Checker has stopped
+// itself, Stop does nothing.
+func (c *Checker) StartOnce() {
+	c.start(true)
+}
+
+// start starts a run of the Checker, unless it is already running, and
+// returns the run's context.
+func (c *Checker) start(once bool) context.Context {
+	c.mu.Lock()
+	if c.running && !c.stopping {
+		defer c.mu.Unlock()
+		return c.ctx
+	}
+	c.mu.Unlock()
+	// the previous run, if stopped, may not have exited yet, and reads the
+	// per-run state that is reset below until it does
+	c.wg.Wait()
+	c.mu.Lock()
+	if c.running {
+		// started concurrently
+		defer c.mu.Unlock()
+		return c.ctx
+	}
+	c.stats = Stats{}
+	c.startedAt = time.Now()
+	c.firstCheckAt, c.firstReachableAt = time.Time{}, time.Time{}
+	c.transitions = nil
+	c.recent, c.latencies = nil, nil
+	// made before running is set, so that a concurrent Stop finds them
+	quit, now := make(chan struct{}, 1), make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	c.quit, c.now, c.ctx, c.cancel = quit, now, ctx, cancel
+	c.running = true
+	c.stopping = false
+	c.mu.Unlock()
+	register(c)
+	c.once = once
+	c.begin()
+	if c.CheckOnResume && ResumeSupported {
+		c.goroutine(func() { c.watchResume(ctx) })
+	}
+	if c.RunFor > 0 {
+		c.goroutine(func() { c.expireAfter(ctx, c.RunFor) })
+	}
+	if c.Pool != nil {
+		c.Pool.add(c, ctx)
+		return ctx
+	}
+	c.goroutine(func() { c.run(ctx, quit, now) })
+	return ctx
+}
+
+// goroutine runs fn on a new goroutine tracked for StopAndWait.
+func (c *Checker) goroutine(fn func()) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		fn()
+	}()
+}
+
+// Stop tells the background goroutine to stop checking. It does not block,
+// so it can be called from a callback of the same Checker, such as a
+// Notifier that stops monitoring once the host is reachable; the callbacks
+// of the cycle in progress still complete, and no further check starts.
+// On a Checker that was never started or is already stopped Stop does
+// nothing, so it can be called more than once and deferred freely.
 func (c *Checker) Stop() {
-	c.quit <- struct{}{}
+	c.stop()
+}
+
+// isRunning reports whether the Checker has been started and not stopped.
+func (c *Checker) isRunning() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running
+}
+
+// stop stops the Checker, and reports whether it was this call that did so.
+func (c *Checker) stop() bool {
+	c.mu.Lock()
+	if !c.running || c.stopping {
+		c.mu.Unlock()
+		return false
+	}
+	c.stopping = true
+	quit, cancel := c.quit, c.cancel
+	if c.probeCancel != nil {
+		c.probeCancel()
+	}
+	c.mu.Unlock()
+	cancel()
+	if c.Pool != nil {
+		if !c.Pool.remove(c) {
+			c.end()
+		}
+		return true
+	}
+	quit <- struct{}{} // buffered, as run may be in a callback calling Stop
+	return true
+}
+
+// StopAndWait is like Stop, but also waits until the Checker's goroutines
+// have exited, so that no further callbacks are made once it returns, other
+// than those already handed to Dispatch. It must not be called from a
+// callback of the same Checker.
+func (c *Checker) StopAndWait() {
+	c.stop()
+	c.wg.Wait()
+}
+
+// CheckNow asks the background goroutine to check immediately rather than
+// wait for the next interval, which then restarts from the end of this check.
+// The check runs even if ShouldCheck would skip it. CheckNow does not wait
+// for the check to complete, and does nothing if the Checker is not running
+// or a check is already pending.
+func (c *Checker) CheckNow() {
+	if c.Pool != nil {
+		c.Pool.checkNow(c)
+		return
+	}
+	c.mu.Lock()
+	now := c.now
+	c.mu.Unlock()
+	select {
+	case now <- struct{}{}:
+	default:
+	}
 }
 
 // Start begins the default Checker instance with the DefaultInterval and
 // enables updates for the NetworkIsReachable function.
+//
+// If the default Checker is already running, calling Start again with the same
+// hostname does nothing, and with a different one stops it and starts it over
+// for the new host, so NetworkIsReachable then reflects only that host. Use
+// StartDefault to refuse such a replacement instead, and DefaultHost to see
+// which host is being checked.
 func Start(hostname string) {
+	startDefault(hostname, defaultInterval(), 0, true)
+}
+
+// StartDefault is like Start, but if the default Checker is already running
+// for a different host it is left running and an error matching
+// ErrAlreadyRunning is returned, so that two parts of a program cannot
+// unknowingly compete for NetworkIsReachable.
+func StartDefault(hostname string) error {
+	return startDefault(hostname, defaultInterval(), 0, false)
+}
+
+// DefaultHost returns the host checked by the default Checker, whose
+// reachability NetworkIsReachable reports, or "" if it is not running.
+func DefaultHost() string {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if !singleton.isRunning() {
+		return ""
+	}
+	return singleton.Hostport
+}
+
+// startDefault starts the default Checker, first stopping it if it is
+// running for another host or settings and replace is set.
+func startDefault(hostname string, interval, timeout time.Duration, replace bool) error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if singleton.isRunning() {
+		same := singleton.Hostport == hostname
+		switch {
+		case same && (!replace || singleton.Interval == interval && singleton.Timeout == timeout):
+			return nil
+		case !replace:
+			return &classError{ErrAlreadyRunning, fmt.Errorf("reachable: default Checker is already checking %s, not %s", singleton.Hostport, hostname)}
+		}
+		singleton.StopAndWait()
+	}
 	singleton.Hostport = hostname
-	singleton.Interval = DefaultInterval
+	singleton.Interval = interval
+	singleton.Timeout = timeout
 	singleton.Notifier = func(a bool) {
 		smu.Lock()
 		sup = a
 		smu.Unlock()
 	}
 	singleton.Start()
+	return nil
 }
 
-// Stop the global instance and reset NetworkIsReachable to true.
+// Stop the global instance and reset NetworkIsReachable to true. Like
+// Checker.Stop it is safe to call more than once, or before Start.
 func Stop() {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	stopDefault()
+}
+
+// StartContext is like Start, but the default Checker also stops, as with
+// Stop, when ctx is done, unless it has been stopped or started over for
+// another host since.
+func StartContext(ctx context.Context, hostname string) {
+	Start(hostname)
+	defaultMu.Lock()
+	stopped := singleton.ctx.Done()
+	defaultMu.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+			defaultMu.Lock()
+			if singleton.ctx.Done() == stopped {
+				stopDefault()
+			}
+			defaultMu.Unlock()
+		case <-stopped:
+		}
+	}()
+}
+
+// stopDefault stops the default Checker. defaultMu must be held.
+func stopDefault() {
 	singleton.Stop()
 	// keep system in a sane/useful state when not running
-	singleton.Notifier(true)
+	if singleton.Notifier != nil {
+		singleton.Notifier(true)
+	}
+}
+
+// InterfaceGate is a policy for the interface check that precedes each probe.
+// See Checker.InterfaceGate.
+type InterfaceGate int
+
+const (
+	// InterfaceGateBlock skips the probe when no interface is up.
+	InterfaceGateBlock InterfaceGate = iota
+
+	// InterfaceGateAdvisory checks the interfaces for reporting only.
+	InterfaceGateAdvisory
+
+	// InterfaceGateOff does not check the interfaces.
+	InterfaceGateOff
+)
+
+// String returns "block", "advisory" or "off".
+func (g InterfaceGate) String() string {
+	switch g {
+	case InterfaceGateBlock:
+		return "block"
+	case InterfaceGateAdvisory:
+		return "advisory"
+	case InterfaceGateOff:
+		return "off"
+	}
+	return fmt.Sprintf("InterfaceGate(%d)", int(g))
+}
+
+// interfaceGate returns the effective InterfaceGate, taking
+// SkipInterfaceCheck into account.
+func (c *Checker) interfaceGate() InterfaceGate {
+	if c.SkipInterfaceCheck {
+		return InterfaceGateOff
+	}
+	return c.InterfaceGate
 }
 
-func (c *Checker) hasInterfaceUp() bool {
+// upInterface returns the first interface that is up, skipping loopback
+// interfaces unless IncludeLoopback is set, or nil if there is none. It only
+// returns an error if the interfaces could not be listed.
+func (c *Checker) upInterface() (*net.Interface, error) {
 	ifaces, err := net.Interfaces()
 	if err != nil {
-		return false
+		return nil, err
 	}
-	for _, x := range ifaces {
-		if (x.Flags & net.FlagLoopback) != 0 {
+	for i, x := range ifaces {
+		if (x.Flags&net.FlagLoopback) != 0 && !c.IncludeLoopback {
 			// loopback doesn't help
 			continue
 		}
 		if (x.Flags & net.FlagUp) != 0 {
-			return true
+			return &ifaces[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// cachedInterface returns the result of scan, reusing the previous one while
+// it is younger than InterfaceCacheTTL.
+func (c *Checker) cachedInterface(scan func() (*net.Interface, error)) (*net.Interface, error) {
+	if c.InterfaceCacheTTL <= 0 {
+		return scan()
+	}
+	if !c.ifaceScanned.IsZero() && time.Since(c.ifaceScanned) < c.InterfaceCacheTTL {
+		return c.ifaceCached, c.ifaceErr
+	}
+	c.ifaceCached, c.ifaceErr = scan()
+	c.ifaceScanned = time.Now()
+	return c.ifaceCached, c.ifaceErr
+}
+
+// routableInterface is like upInterface, but also requires the interface to
+// have a unicast address other than a link-local one.
+func (c *Checker) routableInterface() (*net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i, x := range ifaces {
+		if (x.Flags&net.FlagLoopback) != 0 && !c.IncludeLoopback {
+			continue
+		}
+		if (x.Flags & net.FlagUp) == 0 {
+			continue
+		}
+		addrs, err := x.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipn, ok := a.(*net.IPNet)
+			if !ok || ipn.IP.IsLinkLocalUnicast() || ipn.IP.IsUnspecified() {
+				continue
+			}
+			if ipn.IP.IsGlobalUnicast() || ipn.IP.IsLoopback() {
+				return &ifaces[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// probe attempts to reach the host, returning nil on success and filling in
+// details of the attempt in res. It must honor the deadline of ctx on every
+// path.
+func (c *Checker) probe(ctx context.Context, res *result) error {
+	if c.PingFunc != nil {
+		return c.hooked(c.name(), nil, func() error {
+			return c.PingFunc(ctx)
+		})
+	}
+	if c.ConnFactory != nil {
+		return c.hooked(c.name(), res, func() error {
+			conn, err := c.ConnFactory(ctx)
+			if err != nil {
+				return err
+			}
+			return c.finish(ctx, conn, res)
+		})
+	}
+
+	if c.CaptivePortal {
+		return c.probeCaptive(ctx, res)
+	}
+	if len(c.Interfaces) > 0 {
+		return c.probeUplinks(ctx, res)
+	}
+	return c.probeTargets(ctx, res)
+}
+
+// probeTargets probes the configured ports or hosts.
+func (c *Checker) probeTargets(ctx context.Context, res *result) error {
+	if len(c.Ports) > 0 {
+		return c.probePorts(ctx, res)
+	}
+	hosts := c.hostports()
+	if c.Quorum > 0 {
+		return c.probeQuorum(ctx, hosts, res)
+	}
+	if c.RotateHosts && len(hosts) > 1 {
+		return c.probeRotated(ctx, hosts, res)
+	}
+	if c.ShuffleHosts && len(hosts) > 1 {
+		shuffled := make([]string, len(hosts))
+		for i, j := range c.rand().Perm(len(hosts)) {
+			shuffled[i] = hosts[j]
+		}
+		hosts = shuffled
+	}
+	var err error
+	for _, hp := range hosts {
+		if err = c.probeHost(ctx, hp, res); err == nil || ctx.Err() != nil {
+			break
 		}
 	}
-	return false
+	return err
 }
 
-func (c *Checker) canConnect() bool {
-	if !strings.Contains(c.Hostport, ":") {
-		c.Hostport += ":80"
+// probeHost probes a single hostport, retrying with fresh DNS if enabled.
+func (c *Checker) probeHost(ctx context.Context, hostport string, res *result) error {
+	return c.hooked(hostport, res, func() error {
+		err := c.probeHostOnce(ctx, hostport, res)
+		if err != nil && c.RetryFreshDNS && ctx.Err() == nil && !strings.Contains(hostport, "://") {
+			err = c.redialFresh(ctx, withDefaultPort(hostport), res)
+		}
+		return err
+	})
+}
+
+// hooked runs probe, calling OnProbeStart, OnProbeEnd and OnProbeResult
+// around it. res is where probe records its details, if anywhere.
+func (c *Checker) hooked(host string, res *result, probe func() error) error {
+	if c.OnProbeStart != nil {
+		c.OnProbeStart(host)
+	}
+	start := time.Now()
+	err := probe()
+	latency := time.Since(start)
+	if c.OnProbeEnd != nil {
+		c.OnProbeEnd(host, err == nil, latency, classify(err))
+	}
+	if c.OnProbeResult != nil {
+		pr := ProbeResult{Host: host, Reachable: err == nil, Latency: latency, Err: classify(err)}
+		if res != nil {
+			pr.Target = res.target
+			if err == nil {
+				pr.Addr = res.addr
+			}
+		}
+		c.OnProbeResult(pr)
+	}
+	return err
+}
+
+// ProbeResult describes a single probe for Checker.OnProbeResult.
+type ProbeResult struct {
+	// Host is the entry probed, as passed to OnProbeEnd.
+	Host string
+
+	// Target is the address passed to the dialer, as in Status.DialTarget.
+	// It is an IP address and port when the host was resolved as a
+	// separate step, and empty for PingFunc and ConnFactory probes.
+	Target string
+
+	// Addr is the remote address reached, if the probe succeeded and it is
+	// known.
+	Addr net.Addr
+
+	Reachable bool
+	Latency   time.Duration
+	Err       error
+}
+
+func (c *Checker) probeHostOnce(ctx context.Context, hostport string, res *result) error {
+	if strings.Contains(hostport, "://") {
+		return c.probeURL(ctx, hostport, res)
+	}
+	if err := c.checkVhost(""); err != nil {
+		return err
+	}
+	hostport = withDefaultPort(hostport)
+	res.host = hostport
+	if c.ReuseConn && c.ConnectProxy == "" && c.Send == nil && c.Expect == nil && c.LargeProbeSize <= 0 && c.Inspect == nil && res.source == nil {
+		return c.probeReused(ctx, hostport, res)
+	}
+	if c.HappyEyeballs && c.ConnectProxy == "" && c.network() == "tcp" {
+		return c.probeEyeballs(ctx, hostport, res)
 	}
-	conn, err := net.DialTimeout("tcp", c.Hostport, DefaultTimeout)
+	conn, err := c.connect(ctx, hostport, res)
 	if err != nil {
-		return false
+		return err
 	}
-	conn.Close()
-	return true
+	return c.finish(ctx, conn, res)
 }
 
-func (c *Checker) run() {
-	currentStatus := -1
+// begin resets the per-run state before the first check.
+func (c *Checker) begin() {
+	c.currentStatus = -1
+	c.mu.Lock()
+	if c.restored {
+		c.currentStatus = btoi(c.status.Reachable)
+		c.restored = false
+	}
+	c.mu.Unlock()
+	c.configErr = c.Validate()
+	c.reachedOnce = false
+	c.gated = false
+	c.gaveUp = false
+	c.lastAddr = nil
+	c.hostNotFound = false
+	c.budgetAt = time.Time{}
+	c.lastDelay = 0
+	c.downSince = time.Time{}
+	c.confirming = false
+	c.ifaceScanned = time.Time{}
+	c.outageStart = time.Time{}
+	c.outageSignalled = false
+	c.degrading = false
 	if c.Interval <= time.Duration(0) {
-		c.Interval = DefaultInterval
+		c.Interval = defaultInterval()
+	}
+	if c.Interval < MinInterval {
+		c.debugf("warning: Interval %v is below MinInterval, checking every %v", c.Interval, MinInterval)
 	}
-	t := time.NewTicker(c.Interval)
+}
+
+// end releases per-run resources after the last check.
+func (c *Checker) end() {
+	c.mu.Lock()
+	c.running = false
+	c.cancelTrailing()
+	c.mu.Unlock()
+	unregister(c)
+	c.closeHeld()
+	c.closeSubscribers()
+	c.closeEvents()
+}
+
+// run is the run goroutine, which checks until quit receives. ctx is the
+// run's context, and now receives CheckNow requests.
+func (c *Checker) run(ctx context.Context, quit, now <-chan struct{}) {
+	t := time.NewTimer(c.firstInterval())
+	defer t.Stop()
+	// trailing fires for a notification held back by MinNotifyInterval
+	trailing := time.NewTimer(time.Hour)
+	trailing.Stop()
+	defer trailing.Stop()
+	var trailingC <-chan time.Time
 	for {
+		// a Stop during the last cycle takes precedence over a timer or
+		// CheckNow that is also ready
+		select {
+		case <-quit:
+			c.end()
+			return
+		default:
+		}
 		select {
-		case <-c.quit:
-			t.Stop()
-			close(c.quit)
+		case <-quit:
+			c.end()
 			return
 
 		case <-t.C:
-			isActive := c.hasInterfaceUp()
-			if isActive {
-				isActive = c.canConnect()
-			}
-			if !isActive {
-				if currentStatus != 0 { // already inactive?
-					c.Notifier(false)
-					currentStatus = 0
-				}
-			} else {
-				if currentStatus != 1 { // already active?
-					c.Notifier(true)
-					currentStatus = 1
+			c.tick(ctx, false)
+			t.Reset(c.nextInterval())
+			trailingC = c.armTrailing(trailing)
+
+		case <-now:
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
 				}
 			}
+			c.tick(ctx, true)
+			t.Reset(c.nextInterval())
+			trailingC = c.armTrailing(trailing)
+
+		case <-trailingC:
+			trailingC = nil
+			c.flushTrailing()
+		}
+	}
+}
+
+// tick runs a single polling cycle: check, record, and notify. A forced tick
+// from CheckNow ignores ShouldCheck and SkipProbesInWindows, but not Enabled
+// or Pause. ctx is the run's context.
+func (c *Checker) tick(ctx context.Context, forced bool) {
+	c.flushTrailing()
+	if c.Paused() || (c.Enabled != nil && !c.Enabled()) {
+		c.gated = true
+		if c.AlwaysNotify && c.currentStatus >= 0 {
+			// heartbeat with the held state
+			c.notify(c.currentStatus == 1)
+		}
+		c.heartbeat()
+		return
+	}
+	c.gated = !forced && c.ShouldCheck != nil && !c.ShouldCheck()
+	if c.gated {
+		c.heartbeat()
+		return
+	}
+	quiet := c.inSuppressWindow(c.clock())
+	if !forced && quiet && c.SkipProbesInWindows {
+		c.heartbeat()
+		return
+	}
+	start := c.clock()
+	bytes := c.estimateBytes()
+	if !c.withinBudget(bytes) {
+		c.mu.Lock()
+		c.stats.BudgetSkips++
+		c.mu.Unlock()
+		c.heartbeat()
+		return
+	}
+	if !acquireSlot() {
+		c.heartbeat()
+		return
+	}
+	res := c.check()
+	releaseSlot()
+	if ctx.Err() != nil {
+		// stopped during the probe, whose result is meaningless
+		return
+	}
+	res.bytes = bytes
+	c.debugLog(res)
+	if !res.ok && c.withinSticky() && !(c.NoRouteImmediate && errors.Is(res.err, ErrNoRoute)) {
+		res.held = true
+	}
+	up := res.ok || res.held
+	if up && c.currentStatus == 0 && c.ConfirmUpAfter > 0 && !c.confirming {
+		// hold off until a confirmation probe also succeeds
+		c.confirming = true
+		res.unconfirmed = true
+		up = false
+	} else {
+		c.confirming = false
+	}
+	isActive := btoi(up)
+	changed := c.currentStatus != isActive && !quiet
+	from, to := c.record(res, changed)
+	if c.OnTrace != nil {
+		c.trace(res, start, up, to)
+	}
+	kind := EventProbe
+	if !res.ok {
+		kind = EventError
+	}
+	c.emit(Event{Kind: kind, State: to, Host: res.host, Latency: res.latency, Err: res.err})
+	if quiet {
+		return
+	}
+	significant := from != to && (c.Significant == nil || c.Significant(from, to))
+	if from != to && !significant && changed {
+		// taken as notified without reporting it
+		c.currentStatus = isActive
+		changed = false
+	}
+	if to.reachable() && (from == Down || from == CaptivePortal) {
+		wakeDependents(c)
+	}
+	if significant {
+		c.broadcast(to)
+		c.emit(Event{Kind: EventTransition, State: to, From: from})
+		if c.OnTransition != nil {
+			c.dispatch(func() { c.OnTransition(from, to) })
+		}
+		publishTransition(c, from, to)
+	}
+	baseline := false
+	if changed && c.currentStatus == -1 && c.SkipInitialNotify {
+		// the first result is only a baseline
+		c.currentStatus = isActive
+		changed, baseline = false, true
+	}
+	if !changed && !baseline && c.AlwaysNotify {
+		c.notify(up)
+	}
+	if changed {
+		c.notify(up)
+		recovered := up && c.currentStatus == 0
+		c.currentStatus = isActive
+		if !up && c.OnDown != nil {
+			cause := causeOf(res.err)
+			c.dispatch(func() { c.OnDown(cause) })
+		}
+		if recovered && c.OnReachableAgain != nil {
+			c.dispatch(c.OnReachableAgain)
+		}
+	}
+	c.checkOutage(res)
+	c.checkTrend()
+	c.remindDown()
+	notFound := !res.ok && errors.Is(res.err, ErrHostNotFound)
+	if notFound && !c.hostNotFound && c.OnHostNotFound != nil {
+		err := res.err
+		c.dispatch(func() { c.OnHostNotFound(err) })
+	}
+	c.hostNotFound = notFound
+	c.checkGiveUp(res)
+	if res.ok && res.addr != nil {
+		if old := c.lastAddr; old != nil && old.String() != res.addr.String() && c.OnAddressChange != nil {
+			addr := res.addr
+			c.dispatch(func() { c.OnAddressChange(old, addr) })
+		}
+		c.lastAddr = res.addr
+	}
+	if res.ok && !c.reachedOnce {
+		c.reachedOnce = true
+		if c.OnFirstReachable != nil {
+			c.dispatch(c.OnFirstReachable)
+		}
+		if c.once {
+			c.stop()
 		}
 	}
 }
 
+// deliver delivers a reachability change to the configured notifiers.
+func (c *Checker) deliver(reachable bool) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.mu.Lock()
+	notifier := c.Notifier
+	extra := c.notifiers
+	err, latency := c.status.Err, c.status.Latency
+	ctx := c.ctx
+	c.mu.Unlock()
+	if notifier != nil {
+		c.dispatch(func() { notifier(reachable) })
+	}
+	if c.NotifierCtx != nil {
+		c.dispatch(func() { c.NotifierCtx(ctx, reachable) })
+	}
+	if c.NotifyChan != nil {
+		select {
+		case c.NotifyChan <- reachable:
+		default:
+		}
+	}
+	if c.ReasonNotifier != nil {
+		reason := Reason(err, latency)
+		c.dispatch(func() { c.ReasonNotifier(reachable, reason) })
+	}
+	for _, n := range extra {
+		fn := n.fn
+		c.dispatch(func() { fn(reachable) })
+	}
+}
+
+// dispatch runs a callback inline, or hands it to Dispatch when set.
+func (c *Checker) dispatch(fn func()) {
+	if c.Dispatch != nil {
+		c.Dispatch(fn)
+		return
+	}
+	fn()
+}
+
+// result describes the outcome of a single check.
+type result struct {
+	ok bool
+
+	// latency of the probe, or zero if no probe was made.
+	latency time.Duration
+
+	// ifaceUp is the result of the interface gate, or true if skipped.
+	ifaceUp bool
+
+	// host is the hostport that was probed, if any.
+	host string
+
+	// source, if set, is the local address probes are made from.
+	source net.IP
+
+	// uplinks holds per-interface results when Interfaces is set.
+	uplinks []UplinkStatus
+
+	// hosts holds per-host results when Quorum is set.
+	hosts []HostStatus
+
+	// iface is the interface that satisfied the interface check, if any.
+	iface *net.Interface
+
+	// target is the exact address string passed to the dialer, if any.
+	target string
+
+	// addr is the remote address that was connected to, if known.
+	addr net.Addr
+
+	// dnsTime, connectTime and tlsTime are how long each phase of the probe
+	// took, where measured separately.
+	dnsTime     time.Duration
+	connectTime time.Duration
+	tlsTime     time.Duration
+
+	// family is the network, "tcp4" or "tcp6", that won a HappyEyeballs
+	// race.
+	family string
+
+	// alpn is the protocol negotiated by a tls:// probe.
+	alpn string
+
+	// dials collects the dial attempts for OnTrace, and is nil unless it
+	// is set.
+	dials *dialLog
+
+	// health is the response of a HealthJSON probe, and healthWarn is set
+	// when its status is "warn".
+	health     *Health
+	healthWarn error
+
+	// resolved are the addresses found when DNS resolution was done as a
+	// separate step.
+	resolved []string
+
+	// err is the classified probe error, if any. It may be set even when ok
+	// is true (see serviceDown).
+	err error
+
+	// ports holds per-port results when Ports is set.
+	ports []PortStatus
+
+	// largeErr is set when the LargeProbeSize payload failed even though the
+	// probe itself succeeded.
+	largeErr error
+
+	// serviceDown is set when the network path works but the service
+	// refused the connection, and RefusedIsReachable is set.
+	serviceDown bool
+
+	// captive is set when the captive portal check found interception.
+	captive bool
+
+	// bytes is the estimated data use of the check, for MaxBytesPerHour.
+	bytes int64
+
+	// unconfirmed is set when the check succeeded after the host was down,
+	// but ConfirmUpAfter requires a second success before reporting it.
+	unconfirmed bool
+
+	// held is set when the check failed but the host is still reported
+	// reachable within StickyDuration of its last success.
+	held bool
+}
+
+// check runs the interface gate and probe once. When no interface is up it
+// returns before any socket, DNS lookup or allocation beyond listing the
+// interfaces, as that is the common case on disconnected devices.
+func (c *Checker) check() result {
+	if c.configErr != nil {
+		return result{ok: c.FailOpen, err: c.configErr}
+	}
+	if err := c.dependencyErr(); err != nil {
+		return result{err: err}
+	}
+	if c.InterfaceOnly {
+		iface, err := c.cachedInterface(c.routableInterface)
+		if err != nil {
+			return c.ambiguous(err)
+		}
+		if iface == nil {
+			return result{err: ErrNoInterface}
+		}
+		return result{ok: true, ifaceUp: true, iface: iface}
+	}
+	var iface *net.Interface
+	ifaceUp := true
+	switch c.interfaceGate() {
+	case InterfaceGateBlock:
+		var err error
+		if iface, err = c.cachedInterface(c.upInterface); err != nil {
+			return c.ambiguous(err)
+		}
+		if iface == nil {
+			return result{err: ErrNoInterface}
+		}
+	case InterfaceGateAdvisory:
+		// an error listing the interfaces is reported as none being up
+		iface, _ = c.cachedInterface(c.upInterface)
+		ifaceUp = iface != nil
+	}
+	res := result{ifaceUp: ifaceUp, iface: iface}
+	if c.OnTrace != nil {
+		res.dials = &dialLog{}
+	}
+	ctx, cancel := context.WithTimeout(c.baseContext(), c.timeout())
+	defer cancel()
+	// so that Stop abandons the probe rather than wait out its timeout
+	c.mu.Lock()
+	if c.stopping {
+		cancel()
+	}
+	c.probeCancel = cancel
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.probeCancel = nil
+		c.mu.Unlock()
+	}()
+	start := time.Now()
+	err := c.guardedProbe(ctx, &res)
+	res.latency = time.Since(start)
+	res.err = classify(err)
+	res.ok = err == nil
+	if res.ok && c.MaxLatency > 0 && res.latency > c.MaxLatency {
+		res.ok = false
+		res.err = &classError{ErrTooSlow, fmt.Errorf("reachable: probe took %v, above maximum of %v",
+			res.latency.Round(time.Millisecond), c.MaxLatency)}
+	}
+	if res.ok && res.largeErr != nil {
+		res.err = res.largeErr
+	}
+	if res.ok && res.healthWarn != nil {
+		res.err = res.healthWarn
+	}
+	if c.RefusedIsReachable && errors.Is(res.err, ErrRefused) {
+		res.ok = true
+		res.serviceDown = true
+	}
+	if !res.ok && c.acceptable(res.err) {
+		res.ok = true
+	}
+	if !res.ok && c.FailOpen && errors.Is(res.err, ErrConfig) {
+		res.ok = true
+	}
+	return res
+}
+
+// ambiguous is the result of a check that failed to list the interfaces,
+// reachable only with FailOpen.
+func (c *Checker) ambiguous(err error) result {
+	return result{ok: c.FailOpen, ifaceUp: c.FailOpen, err: &classError{ErrInterfaceList, err}}
+}
+
+// guardedProbe runs probe, on a goroutine of its own when ProbeWatchdog is
+// set, so that a probe ignoring its context cannot stall the Checker. If the
+// probe has not returned within ProbeWatchdog of the deadline of ctx it is
+// abandoned, and ErrProbeStuck returned; the probe works on a private copy of
+// res, so that it cannot write into a later check.
+func (c *Checker) guardedProbe(ctx context.Context, res *result) error {
+	if c.ProbeWatchdog <= 0 {
+		return c.probe(ctx, res)
+	}
+	type outcome struct {
+		res result
+		err error
+	}
+	r := *res
+	if r.dials != nil {
+		r.dials = &dialLog{}
+	}
+	done := make(chan outcome, 1)
+	go func(r result) {
+		err := c.probe(ctx, &r)
+		done <- outcome{r, err}
+	}(r)
+
+	wait := c.ProbeWatchdog
+	if deadline, ok := ctx.Deadline(); ok {
+		wait += time.Until(deadline)
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case o := <-done:
+		*res = o.res
+		return o.err
+	case <-t.C:
+		return ErrProbeStuck
+	}
+}
+
+// acceptable reports whether err matches ReachableOnErrors or
+// ReachableOnError.
+func (c *Checker) acceptable(err error) bool {
+	for _, target := range c.ReachableOnErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return c.ReachableOnError != nil && c.ReachableOnError(err)
+}
+
+// baseContext returns the parent context for a probe.
+func (c *Checker) baseContext() context.Context {
+	if c.BaseContext != nil {
+		if ctx := c.BaseContext(); ctx != nil {
+			return ctx
+		}
+	}
+	return context.Background()
+}
+
+// record updates the status snapshot and statistics after a check, and
+// returns the previous and new State.
+func (c *Checker) record(res result, changed bool) (from, to State) {
+	now := c.clock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if res.ok {
+		c.status.LastSuccess = now
+		c.status.Addr = ""
+		if res.addr != nil {
+			c.status.Addr = res.addr.String()
+		}
+		c.status.Family = res.family
+		c.status.ALPN = res.alpn
+		c.status.Latency = c.smoothLatency(c.status.Latency, res.latency)
+	} else {
+		c.status.LastFailure = now
+	}
+	c.status.InterfaceUp = res.ifaceUp
+	if res.ifaceUp {
+		c.status.LastInterfaceUp = now
+	}
+	c.status.Interface = ""
+	c.status.Metered = false
+	if res.iface != nil {
+		c.status.Interface = res.iface.Name
+		c.status.Metered = c.IsMetered != nil && c.IsMetered(*res.iface)
+	}
+	c.status.Host = res.host
+	c.status.DNSTime = res.dnsTime
+	c.status.ConnectTime = res.connectTime
+	c.status.TLSTime = res.tlsTime
+	c.status.DialTarget = res.target
+	if res.resolved != nil {
+		c.status.Resolved = res.resolved
+	}
+	c.status.Ports = res.ports
+	c.status.Uplinks = res.uplinks
+	c.status.Hosts = res.hosts
+	c.status.Err = res.err
+	c.status.Error = ""
+	if res.err != nil {
+		c.status.Error = res.err.Error()
+	}
+	c.status.ServiceDown = res.serviceDown
+	c.status.TLSFailed = errors.Is(res.err, ErrTLSHandshake)
+	c.status.UnexpectedAddr = ""
+	var unexpected *unexpectedAddrError
+	if errors.As(res.err, &unexpected) {
+		c.status.UnexpectedAddr = unexpected.addr
+	}
+	c.status.Health = res.health
+	if changed {
+		c.status.Reachable = res.ok || res.held
+		c.status.LastChange = now
+	}
+	c.stats.add(res)
+	c.addSample(res.ok)
+	if res.ok {
+		c.addLatency(res.latency)
+	}
+	if c.firstCheckAt.IsZero() {
+		c.firstCheckAt = time.Now()
+	}
+	if res.ok && c.firstReachableAt.IsZero() {
+		c.firstReachableAt = time.Now()
+	}
+	c.status.ConsecutiveCount = c.stats.Streak
+
+	from = c.status.State
+	switch {
+	case res.captive && !res.held:
+		to = CaptivePortal
+	case !res.ok && !res.held:
+		to = Down
+	case res.held, res.unconfirmed:
+		to = from
+	case res.largeErr != nil, res.healthWarn != nil:
+		to = Degraded
+	case c.DegradedThreshold > 0 && c.status.Latency > c.DegradedThreshold:
+		to = Degraded
+	default:
+		to = Up
+	}
+	c.status.State = to
+	if from != to {
+		t := Transition{At: now, From: from, To: to, Latency: res.latency}
+		if res.err != nil {
+			t.Err = res.err.Error()
+		}
+		c.addTransition(t)
+	}
+	if to != Down {
+		c.status.DownCause = CauseOther
+	} else if !res.unconfirmed {
+		c.status.DownCause = causeOf(res.err)
+	}
+	c.status.LastCheck = now
+	if c.checked != nil {
+		close(c.checked)
+		c.checked = nil
+	}
+	return from, to
+}
+
+// withinSticky reports whether the last successful check was recent enough
+// for StickyDuration to keep the host reachable.
+func (c *Checker) withinSticky() bool {
+	if c.StickyDuration <= 0 || c.currentStatus != 1 {
+		return false
+	}
+	c.mu.Lock()
+	last := c.status.LastSuccess
+	c.mu.Unlock()
+	return c.clock().Sub(last) < c.StickyDuration
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 // NetworkIsReachable returns true when the package is able to reach the
 // configured host. Start("example.com") must be called for this to be valid.
 // If Start is not called, the default value is true.
 func NetworkIsReachable() bool {
 	smu.Lock()
 	defer smu.Unlock()
+	if forced != Unknown && time.Now().Before(forcedUntil) {
+		return forced == Up
+	}
 	return sup
 }
+
+// Reachable is shorthand for NetworkIsReachable. Like it, the result is read
+// under a lock, so it is safe to call from any goroutine, and it is true until
+// the default Checker has been started and found the host unreachable.
+func Reachable() bool {
+	return NetworkIsReachable()
+}
+
+// NetworkStatus returns the Status of the default Checker started by Start:
+// when it last checked, whether that succeeded, the smoothed latency, and its
+// error, such as one matching ErrTimeout, ErrRefused or ErrNoInterface. It is
+// safe to call from any goroutine. Before Start its State is Unknown.
+func NetworkStatus() Status {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return singleton.Status()
+}
@@ -0,0 +1,263 @@
+package reachable
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// pinged returns a Checker probing with PingFunc, so that tests need no
+// network, and that does not gate probes on the local interfaces.
+func pinged(ping func(ctx context.Context) error) *Checker {
+	return &Checker{
+		Name:               "test",
+		PingFunc:           ping,
+		SkipInterfaceCheck: true,
+		Interval:           MinInterval,
+	}
+}
+
+func ok(context.Context) error { return nil }
+
+// within fails the test unless fn returns before d has passed.
+func within(t *testing.T, d time.Duration, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(d):
+		t.Fatalf("%s did not return within %v", what, d)
+	}
+}
+
+// settleGoroutines waits for the number of goroutines to fall back to at most
+// n, and fails the test if it does not.
+func settleGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines left, want at most %d:\n%s", runtime.NumGoroutine(), n, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStopFromNotifier(t *testing.T) {
+	for _, pool := range []*Pool{nil, NewPool(2)} {
+		c := pinged(ok)
+		c.Pool = pool
+		notified := make(chan bool, 10)
+		c.Notifier = func(r bool) {
+			c.Stop()
+			notified <- r
+		}
+		c.Start()
+		select {
+		case r := <-notified:
+			if !r {
+				t.Errorf("pool %v: notified %v, want true", pool != nil, r)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("pool %v: no notification", pool != nil)
+		}
+		within(t, 5*time.Second, "StopAndWait", c.StopAndWait)
+		if c.isRunning() {
+			t.Errorf("pool %v: still running after Stop from Notifier", pool != nil)
+		}
+		if len(notified) != 0 {
+			t.Errorf("pool %v: notified again after Stop", pool != nil)
+		}
+	}
+}
+
+func TestRestartWaitsForPreviousRun(t *testing.T) {
+	base := runtime.NumGoroutine()
+	c := pinged(ok)
+	for i := 0; i < 20; i++ {
+		c.Start()
+		c.CheckNow()
+		c.Stop()
+	}
+	c.Start()
+	within(t, 5*time.Second, "StopAndWait", c.StopAndWait)
+	settleGoroutines(t, base)
+}
+
+func TestStopInAnyState(t *testing.T) {
+	c := pinged(ok)
+	c.Stop() // never started
+	c.StopAndWait()
+	c.Start()
+	c.Stop()
+	c.Stop() // already stopping
+	within(t, 5*time.Second, "StopAndWait", c.StopAndWait)
+	c.Stop() // already stopped
+	Stop()   // the default Checker, never started
+	Stop()
+}
+
+func TestStopStartStopAndWait(t *testing.T) {
+	base := runtime.NumGoroutine()
+	checks := make(chan struct{}, 100)
+	c := pinged(func(context.Context) error {
+		select {
+		case checks <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	c.Start()
+	<-checks
+	c.Stop()
+	c.Start()
+	select {
+	case <-checks:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no check after restart")
+	}
+	if !c.isRunning() {
+		t.Error("not running after restart")
+	}
+	within(t, 5*time.Second, "StopAndWait", c.StopAndWait)
+	settleGoroutines(t, base)
+}
+
+// blocking returns a PingFunc that signals started and then blocks until its
+// context is done.
+func blocking(started chan<- struct{}) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func TestStopAbandonsProbe(t *testing.T) {
+	started := make(chan struct{}, 1)
+	c := pinged(blocking(started))
+	c.Timeout = time.Hour
+	notified := make(chan bool, 1)
+	c.Notifier = func(r bool) { notified <- r }
+	c.Start()
+	<-started
+	within(t, time.Second, "StopAndWait during a probe", c.StopAndWait)
+	select {
+	case r := <-notified:
+		t.Errorf("notified %v for a probe abandoned by Stop", r)
+	default:
+	}
+	if st, n := c.Status(), c.Stats().Checks; st.State != Unknown || n != 0 {
+		t.Errorf("abandoned probe recorded: %v after %d checks", st.State, n)
+	}
+}
+
+func TestStartContext(t *testing.T) {
+	started := make(chan struct{}, 1)
+	c := pinged(blocking(started))
+	c.Timeout = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	c.StartContext(ctx)
+	<-started
+	cancel()
+	within(t, time.Second, "StopAndWait after cancel", c.StopAndWait)
+	if c.isRunning() {
+		t.Error("still running after its context was cancelled")
+	}
+}
+
+// offline returns a Checker that finds no interface up, from a cached scan
+// so that the result does not depend on the machine running the test.
+func offline() *Checker {
+	c := &Checker{Hostport: "example.com:80", InterfaceCacheTTL: time.Hour}
+	c.begin()
+	c.ifaceScanned = time.Now()
+	return c
+}
+
+func TestOfflineCheckDoesNotAllocate(t *testing.T) {
+	c := offline()
+	if res := c.check(); res.err != ErrNoInterface {
+		t.Fatalf("offline check: %v, want ErrNoInterface", res.err)
+	}
+	if n := testing.AllocsPerRun(100, func() { c.check() }); n != 0 {
+		t.Errorf("offline check made %v allocations, want 0", n)
+	}
+}
+
+func BenchmarkOfflineCheck(b *testing.B) {
+	c := offline()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.check()
+	}
+}
+
+func TestHangingProbeIsAbandoned(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := pinged(func(context.Context) error {
+		<-release // ignoring the context
+		return nil
+	})
+	c.Timeout = 50 * time.Millisecond
+	c.ProbeWatchdog = 50 * time.Millisecond
+	for i := 0; i < 2; i++ {
+		var st Status
+		within(t, time.Second, "a check with a hanging probe", func() { st = c.Step() })
+		if st.State != Down || !errors.Is(st.Err, ErrProbeStuck) || st.DownCause != CauseTimeout {
+			t.Errorf("check %d: %v with %v (cause %v), want down with ErrProbeStuck", i, st.State, st.Err, st.DownCause)
+		}
+	}
+}
+
+// goroutineID returns the first line of the calling goroutine's stack trace,
+// which names it.
+func goroutineID() string {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	return string(buf[:bytes.IndexByte(buf, '[')])
+}
+
+func TestProbeRunsInlineWithoutWatchdog(t *testing.T) {
+	var probed string
+	c := pinged(func(context.Context) error {
+		probed = goroutineID()
+		return nil
+	})
+	c.Step()
+	if self := goroutineID(); probed != self {
+		t.Errorf("probe ran on %q, want the checking goroutine %q", probed, self)
+	}
+}
+
+func TestStartOnceStopsItself(t *testing.T) {
+	for _, pool := range []*Pool{nil, NewPool(2)} {
+		c := pinged(ok)
+		c.Pool = pool
+		notified := make(chan bool, 10)
+		c.Notifier = func(r bool) { notified <- r }
+		c.StartOnce()
+		if r := <-notified; !r {
+			t.Errorf("pool %v: first notification %v, want true", pool != nil, r)
+		}
+		// the run must end by itself, with nothing left for Stop to do
+		within(t, 5*time.Second, "the run after StartOnce", func() {
+			for c.isRunning() {
+				time.Sleep(time.Millisecond)
+			}
+			c.wg.Wait()
+		})
+	}
+}
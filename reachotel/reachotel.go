@@ -0,0 +1,251 @@
+//go:build otel
+
+// Package reachotel records reachable.Checker probes as OpenTelemetry spans
+// and metrics. It needs the OpenTelemetry API, so that the core package stays
+// free of dependencies, and is only built with the "otel" build tag:
+//
+//    go build -tags otel
+//
+// Attach an Instrumentation to each Checker before starting it:
+//
+//    in, err := reachotel.New(otel.GetMeterProvider(), otel.GetTracerProvider())
+//    if err != nil {
+//        return err
+//    }
+//    c := &reachable.Checker{Hostport: "db:5432"}
+//    in.Attach(c)
+//    c.Start()
+//
+// Every probe becomes a client span named "reachable.probe", and these
+// instruments are recorded, each with a "checker" attribute holding the
+// Checker's name:
+//
+//    reachable.probes          counter of probes, with "host" and "result" ("success" or "failure")
+//    reachable.probe.duration  histogram of probe latency in seconds, with "host" and "result"
+//    reachable.transitions     counter of State changes, with "from" and "to"
+//    reachable.up              gauge of 1 while the State is reachable, 0 otherwise
+//
+// For anycast and round-robin hosts, set Instrumentation.IPLabels to add an
+// "ip" attribute with the address each probe reached, or dialed when it
+// failed, to the probe span and metrics, revealing a bad point of presence
+// that the aggregate hides. ReverseDNS adds its reverse DNS name as
+// "ip.name" too. Each address seen adds its own time series, so leave them
+// off for hosts with many addresses.
+package reachotel
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pbnjay/reachable"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the meter and tracer.
+const ScopeName = "github.com/pbnjay/reachable/reachotel"
+
+// Instrumentation records the probes and transitions of the Checkers
+// attached to it.
+type Instrumentation struct {
+	// IPLabels adds the "ip" attribute to the probes of Checkers attached
+	// after it is set, and ReverseDNS the "ip.name" attribute as well. The
+	// reverse lookup of each address is made once, in the background, and
+	// "ip.name" is omitted until it completes or if it fails.
+	IPLabels   bool
+	ReverseDNS bool
+
+	tracer      trace.Tracer
+	probes      metric.Int64Counter
+	duration    metric.Float64Histogram
+	transitions metric.Int64Counter
+
+	mu       sync.Mutex
+	checkers []*reachable.Checker
+	names    map[string]string // reverse DNS names by IP, "" while looking up
+}
+
+// New creates the instruments with the given providers. Either provider may
+// be nil to record only spans or only metrics.
+func New(mp metric.MeterProvider, tp trace.TracerProvider) (*Instrumentation, error) {
+	in := &Instrumentation{}
+	if tp != nil {
+		in.tracer = tp.Tracer(ScopeName)
+	}
+	if mp == nil {
+		return in, nil
+	}
+	meter := mp.Meter(ScopeName)
+	var err error
+	in.probes, err = meter.Int64Counter("reachable.probes",
+		metric.WithDescription("Reachability probes made."))
+	if err != nil {
+		return nil, err
+	}
+	in.duration, err = meter.Float64Histogram("reachable.probe.duration",
+		metric.WithDescription("Latency of reachability probes."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	in.transitions, err = meter.Int64Counter("reachable.transitions",
+		metric.WithDescription("Reachability state changes."))
+	if err != nil {
+		return nil, err
+	}
+	_, err = meter.Int64ObservableGauge("reachable.up",
+		metric.WithDescription("Whether the host is reachable (1) or not (0)."),
+		metric.WithInt64Callback(in.observeUp))
+	if err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// Attach instruments c through its OnProbeEnd and OnTransition hooks,
+// calling any hooks already set afterwards. It must be called before c is
+// started.
+func (in *Instrumentation) Attach(c *reachable.Checker) {
+	in.mu.Lock()
+	in.checkers = append(in.checkers, c)
+	in.mu.Unlock()
+
+	name := c.Status().Name
+	checker := attribute.String("checker", name)
+
+	if in.IPLabels {
+		prevResult := c.OnProbeResult
+		c.OnProbeResult = func(pr reachable.ProbeResult) {
+			in.probe(append(in.ipAttrs(pr), checker), pr.Host, pr.Reachable, pr.Latency, pr.Err)
+			if prevResult != nil {
+				prevResult(pr)
+			}
+		}
+	} else {
+		prevEnd := c.OnProbeEnd
+		c.OnProbeEnd = func(host string, ok bool, latency time.Duration, err error) {
+			in.probe([]attribute.KeyValue{checker}, host, ok, latency, err)
+			if prevEnd != nil {
+				prevEnd(host, ok, latency, err)
+			}
+		}
+	}
+	prevTransition := c.OnTransition
+	c.OnTransition = func(from, to reachable.State) {
+		if in.transitions != nil {
+			in.transitions.Add(context.Background(), 1, metric.WithAttributes(checker,
+				attribute.String("from", from.String()), attribute.String("to", to.String())))
+		}
+		if prevTransition != nil {
+			prevTransition(from, to)
+		}
+	}
+}
+
+// probe records a finished probe with attrs and its own attributes. The span
+// is created afterwards with the probe's start time, so that concurrent
+// probes need no bookkeeping.
+func (in *Instrumentation) probe(attrs []attribute.KeyValue, host string, ok bool, latency time.Duration, err error) {
+	ctx := context.Background()
+	result := "success"
+	if !ok {
+		result = "failure"
+	}
+	attrs = append(attrs, attribute.String("host", host), attribute.String("result", result))
+	if in.probes != nil {
+		in.probes.Add(ctx, 1, metric.WithAttributes(attrs...))
+		in.duration.Record(ctx, latency.Seconds(), metric.WithAttributes(attrs...))
+	}
+	if in.tracer == nil {
+		return
+	}
+	end := time.Now()
+	_, span := in.tracer.Start(ctx, "reachable.probe",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(end.Add(-latency)),
+		trace.WithAttributes(attrs...))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// ipAttrs returns the "ip" and "ip.name" attributes for a probe: the address
+// it reached, or else the one it dialed if that is an IP address.
+func (in *Instrumentation) ipAttrs(pr reachable.ProbeResult) []attribute.KeyValue {
+	var ip string
+	if pr.Addr != nil {
+		ip = hostOf(pr.Addr.String())
+	} else if h := hostOf(pr.Target); net.ParseIP(h) != nil {
+		ip = h
+	}
+	if ip == "" {
+		return nil
+	}
+	attrs := []attribute.KeyValue{attribute.String("ip", ip)}
+	if in.ReverseDNS {
+		if name := in.reverseName(ip); name != "" {
+			attrs = append(attrs, attribute.String("ip.name", name))
+		}
+	}
+	return attrs
+}
+
+// hostOf returns the host part of a host and port.
+func hostOf(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return hostport
+}
+
+// reverseName returns the cached reverse DNS name of ip, starting a lookup if
+// there is none yet.
+func (in *Instrumentation) reverseName(ip string) string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if name, ok := in.names[ip]; ok {
+		return name
+	}
+	if in.names == nil {
+		in.names = make(map[string]string)
+	}
+	in.names[ip] = ""
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		names, err := net.DefaultResolver.LookupAddr(ctx, ip)
+		if err != nil || len(names) == 0 {
+			return
+		}
+		in.mu.Lock()
+		in.names[ip] = strings.TrimSuffix(names[0], ".")
+		in.mu.Unlock()
+	}()
+	return ""
+}
+
+// observeUp reports the reachable.up gauge for every attached Checker whose
+// State is known.
+func (in *Instrumentation) observeUp(_ context.Context, o metric.Int64Observer) error {
+	in.mu.Lock()
+	checkers := append([]*reachable.Checker(nil), in.checkers...)
+	in.mu.Unlock()
+	for _, c := range checkers {
+		st := c.Status()
+		if st.State == reachable.Unknown {
+			continue
+		}
+		up := int64(0)
+		if st.State == reachable.Up || st.State == reachable.Degraded {
+			up = 1
+		}
+		o.Observe(up, metric.WithAttributes(attribute.String("checker", st.Name)))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+//go:build otel
+
+package reachotel
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pbnjay/reachable"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// collected returns the metric named name from rm, or fails the test.
+func collected(t *testing.T, rm metricdata.ResourceMetrics, name string) metricdata.Aggregation {
+	t.Helper()
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m.Data
+			}
+		}
+	}
+	t.Fatalf("no %s metric recorded", name)
+	return nil
+}
+
+// attr returns the value of attribute key in set, or "".
+func attr(set attribute.Set, key string) string {
+	v, _ := set.Value(attribute.Key(key))
+	return v.AsString()
+}
+
+func TestAttach(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	in, err := New(mp, tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var up int32 = 1
+	c := &reachable.Checker{
+		Name: "db",
+		PingFunc: func(context.Context) error {
+			if atomic.LoadInt32(&up) == 0 {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+		SkipInterfaceCheck: true,
+	}
+	in.Attach(c)
+	c.Step()
+	atomic.StoreInt32(&up, 0)
+	c.Step()
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("%d spans, want one per probe", len(ended))
+	}
+	for i, s := range ended {
+		if s.Name() != "reachable.probe" || s.SpanKind() != trace.SpanKindClient {
+			t.Errorf("span %d is %s of kind %v, want a reachable.probe client span", i, s.Name(), s.SpanKind())
+		}
+	}
+	if code := ended[0].Status().Code; code == codes.Error {
+		t.Error("successful probe's span has an error status")
+	}
+	if code := ended[1].Status().Code; code != codes.Error {
+		t.Errorf("failed probe's span has status %v, want Error", code)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	probes := collected(t, rm, "reachable.probes").(metricdata.Sum[int64])
+	results := map[string]int64{}
+	for _, dp := range probes.DataPoints {
+		if attr(dp.Attributes, "checker") != "db" || attr(dp.Attributes, "host") != "db" {
+			t.Errorf("probe attributes %v, want checker and host db", dp.Attributes.ToSlice())
+		}
+		results[attr(dp.Attributes, "result")] += dp.Value
+	}
+	if results["success"] != 1 || results["failure"] != 1 {
+		t.Errorf("probes by result %v, want one success and one failure", results)
+	}
+
+	transitions := collected(t, rm, "reachable.transitions").(metricdata.Sum[int64])
+	changes := map[string]int64{}
+	for _, dp := range transitions.DataPoints {
+		changes[attr(dp.Attributes, "from")+">"+attr(dp.Attributes, "to")] += dp.Value
+	}
+	if changes["unknown>up"] != 1 || changes["up>down"] != 1 || len(changes) != 2 {
+		t.Errorf("transitions %v, want unknown>up and up>down", changes)
+	}
+
+	gauge := collected(t, rm, "reachable.up").(metricdata.Gauge[int64])
+	if len(gauge.DataPoints) != 1 || gauge.DataPoints[0].Value != 0 {
+		t.Errorf("reachable.up %v, want one point of 0 once down", gauge.DataPoints)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows || plan9
+
+package reachsyslog
+
+// dial fails: log/syslog is not available on this platform.
+func dial(facility Facility, tag string) (writer, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,10 @@
+//go:build !windows && !plan9
+
+package reachsyslog
+
+import "log/syslog"
+
+// dial connects to the local syslog daemon.
+func dial(facility Facility, tag string) (writer, error) {
+	return syslog.New(syslog.Priority(facility)|syslog.LOG_INFO, tag)
+}
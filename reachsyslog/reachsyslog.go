@@ -0,0 +1,125 @@
+// Package reachsyslog logs a reachable.Checker's State changes to the system
+// log daemon, for deployments that collect logs through syslog rather than a
+// structured logger. Changes to Up are logged at info severity, to Degraded at
+// warning, and to Down or CaptivePortal at err, with the reason for the
+// change:
+//
+//    l, err := reachsyslog.New(reachsyslog.Daemon, "myapp")
+//    if err != nil {
+//        return err
+//    }
+//    defer l.Close()
+//    c := &reachable.Checker{Name: "db", Hostport: "db:5432"}
+//    l.Attach(c)
+//    c.Start()
+//
+// logs lines such as "db down (was up): connection refused". A single Logger
+// can be attached to any number of Checkers.
+//
+// It uses log/syslog, which is not available on Windows or Plan 9; there New
+// returns ErrUnsupported, and event logs must be fed by other means.
+package reachsyslog
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pbnjay/reachable"
+)
+
+// ErrUnsupported is returned by New on platforms without syslog.
+var ErrUnsupported = errors.New("reachsyslog: syslog is not supported on this platform")
+
+// Facility is the syslog facility messages are logged under. Its values are
+// those of log/syslog.
+type Facility int
+
+// Facilities commonly used by services.
+const (
+	User   Facility = 1 << 3
+	Daemon Facility = 3 << 3
+	Local0 Facility = 16 << 3
+	Local1 Facility = 17 << 3
+	Local2 Facility = 18 << 3
+	Local3 Facility = 19 << 3
+	Local4 Facility = 20 << 3
+	Local5 Facility = 21 << 3
+	Local6 Facility = 22 << 3
+	Local7 Facility = 23 << 3
+)
+
+// writer is the part of a *syslog.Writer that Logger uses.
+type writer interface {
+	Info(msg string) error
+	Warning(msg string) error
+	Err(msg string) error
+	Close() error
+}
+
+// Logger logs State changes to syslog. It is safe for concurrent use.
+type Logger struct {
+	// ErrorLog, if set, is called when a message could not be logged.
+	ErrorLog func(error)
+
+	mu sync.Mutex
+	w  writer
+}
+
+// New connects to the local syslog daemon, to log under facility with tag,
+// which is the program name if empty.
+func New(facility Facility, tag string) (*Logger, error) {
+	w, err := dial(facility, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{w: w}, nil
+}
+
+// Attach logs every State change of c, chaining any OnTransition already set.
+// It must be called before c is started.
+func (l *Logger) Attach(c *reachable.Checker) {
+	name := c.Status().Name
+	prev := c.OnTransition
+	c.OnTransition = func(from, to reachable.State) {
+		st := c.Status()
+		l.log(to, fmt.Sprintf("%s %s (was %s): %s", name, to, from, reachable.Reason(st.Err, st.Latency)))
+		if prev != nil {
+			prev(from, to)
+		}
+	}
+}
+
+// log writes msg at the severity for a change to state.
+func (l *Logger) log(state reachable.State, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		return
+	}
+	var err error
+	switch state {
+	case reachable.Down, reachable.CaptivePortal:
+		err = l.w.Err(msg)
+	case reachable.Degraded:
+		err = l.w.Warning(msg)
+	default:
+		err = l.w.Info(msg)
+	}
+	if err != nil && l.ErrorLog != nil {
+		l.ErrorLog(err)
+	}
+}
+
+// Close closes the connection to the syslog daemon. Changes of Checkers the
+// Logger is attached to are no longer logged.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		return nil
+	}
+	err := l.w.Close()
+	l.w = nil
+	return err
+}
@@ -0,0 +1,96 @@
+//go:build !windows && !plan9
+
+package reachsyslog
+
+import (
+	"context"
+	"errors"
+	"log/syslog"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pbnjay/reachable"
+)
+
+func TestAttach(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	w, err := syslog.Dial("udp", pc.LocalAddr().String(), syslog.Priority(Daemon)|syslog.LOG_INFO, "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &Logger{w: w, ErrorLog: func(err error) { t.Error(err) }}
+	defer l.Close()
+
+	var up int32 = 1
+	c := &reachable.Checker{
+		Name: "db",
+		PingFunc: func(context.Context) error {
+			if atomic.LoadInt32(&up) == 0 {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+		SkipInterfaceCheck: true,
+	}
+	l.Attach(c)
+
+	next := func() string {
+		buf := make([]byte, 1024)
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+	tests := []struct {
+		up       int32
+		priority string // the facility and severity, daemon.info or daemon.err
+		msg      string
+	}{
+		{1, "<30>", "db up (was unknown): ok"},
+		{0, "<27>", "db down (was up): connection refused"},
+	}
+	for _, tt := range tests {
+		atomic.StoreInt32(&up, tt.up)
+		c.Step()
+		line := next()
+		if !strings.HasPrefix(line, tt.priority) || !strings.Contains(line, " myapp[") {
+			t.Errorf("line %q, want priority %s and tag myapp", line, tt.priority)
+		}
+		if !strings.Contains(line, "]: "+tt.msg) {
+			t.Errorf("line %q, want message %q", line, tt.msg)
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	w, err := syslog.Dial("udp", pc.LocalAddr().String(), syslog.LOG_INFO, "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &Logger{w: w}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("second Close() = %v, want nil", err)
+	}
+	l.log(reachable.Down, "after Close") // must not panic or send
+	pc.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, _, err := pc.ReadFrom(make([]byte, 64)); err == nil {
+		t.Errorf("got %d bytes after Close, want nothing logged", n)
+	}
+}
@@ -0,0 +1,127 @@
+package reachable
+
+import "sync"
+
+// anyTransitionBuffer is how many undelivered transitions are queued for the
+// OnAnyTransition hooks before the oldest is dropped.
+const anyTransitionBuffer = 1024
+
+// registry tracks every running Checker in the process.
+var registry struct {
+	mu       sync.Mutex
+	checkers map[*Checker]struct{}
+
+	hooks      []anyHook
+	nextID     int
+	queue      []transitionEvent
+	delivering bool
+}
+
+type anyHook struct {
+	id int
+	fn func(c *Checker, from, to State)
+}
+
+type transitionEvent struct {
+	c        *Checker
+	from, to State
+}
+
+// Running returns every Checker in the process that has been started and not
+// yet stopped, in no particular order.
+func Running() []*Checker {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	list := make([]*Checker, 0, len(registry.checkers))
+	for c := range registry.checkers {
+		list = append(list, c)
+	}
+	return list
+}
+
+// OnAnyTransition registers fn to be called whenever any running Checker in
+// the process changes State, and returns a function that removes it again.
+// Use c.Status().Name to tell Checkers apart, and c.Tags to route the
+// transition.
+//
+// The hooks are called one transition at a time, in the order the
+// transitions happened, from a separate goroutine, so a slow hook never
+// delays any Checker. For the same reason a hook is not ordered with the
+// Checker's own OnTransition and other callbacks, and may run before, while
+// or after they do. If the hooks fall more than 1024 transitions behind, the
+// oldest undelivered ones are dropped.
+func OnAnyTransition(fn func(c *Checker, from, to State)) (remove func()) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.nextID++
+	id := registry.nextID
+	registry.hooks = append(registry.hooks[:len(registry.hooks):len(registry.hooks)], anyHook{id, fn})
+
+	return func() {
+		registry.mu.Lock()
+		defer registry.mu.Unlock()
+		for i, h := range registry.hooks {
+			if h.id == id {
+				rest := make([]anyHook, 0, len(registry.hooks)-1)
+				rest = append(rest, registry.hooks[:i]...)
+				registry.hooks = append(rest, registry.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// register adds c to the running Checkers.
+func register(c *Checker) {
+	registry.mu.Lock()
+	if registry.checkers == nil {
+		registry.checkers = make(map[*Checker]struct{})
+	}
+	registry.checkers[c] = struct{}{}
+	registry.mu.Unlock()
+}
+
+// unregister removes c from the running Checkers.
+func unregister(c *Checker) {
+	registry.mu.Lock()
+	delete(registry.checkers, c)
+	registry.mu.Unlock()
+}
+
+// publishTransition queues a transition of c for the OnAnyTransition hooks,
+// starting a goroutine to deliver it if one is not already running.
+func publishTransition(c *Checker, from, to State) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if len(registry.hooks) == 0 {
+		return
+	}
+	if len(registry.queue) == anyTransitionBuffer {
+		copy(registry.queue, registry.queue[1:])
+		registry.queue = registry.queue[:anyTransitionBuffer-1]
+	}
+	registry.queue = append(registry.queue, transitionEvent{c, from, to})
+	if !registry.delivering {
+		registry.delivering = true
+		go deliverTransitions()
+	}
+}
+
+// deliverTransitions calls the hooks for queued transitions until the queue
+// is empty.
+func deliverTransitions() {
+	registry.mu.Lock()
+	for len(registry.queue) > 0 {
+		ev := registry.queue[0]
+		registry.queue = registry.queue[1:]
+		hooks := registry.hooks
+		registry.mu.Unlock()
+		for _, h := range hooks {
+			h.fn(ev.c, ev.from, ev.to)
+		}
+		registry.mu.Lock()
+	}
+	registry.queue = nil
+	registry.delivering = false
+	registry.mu.Unlock()
+}
@@ -0,0 +1,58 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOnAnyTransition(t *testing.T) {
+	var up int32 = 1
+	c := pinged(func(context.Context) error {
+		if atomic.LoadInt32(&up) == 0 {
+			return errors.New("down")
+		}
+		return nil
+	})
+	seen := make(chan State, 10)
+	remove := OnAnyTransition(func(from *Checker, _, to State) {
+		if from == c {
+			seen <- to
+		}
+	})
+	c.Start()
+	defer c.StopAndWait()
+	select {
+	case to := <-seen:
+		if to != Up {
+			t.Errorf("first transition to %v, want Up", to)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no transition seen")
+	}
+
+	remove()
+	remove() // a second call does nothing
+	atomic.StoreInt32(&up, 0)
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Status().State != Down && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// wait for the delivery goroutine to have drained the transition
+	for {
+		registry.mu.Lock()
+		idle := !registry.delivering
+		registry.mu.Unlock()
+		if idle {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case to := <-seen:
+		t.Errorf("removed hook called with a transition to %v", to)
+	default:
+	}
+}
@@ -0,0 +1,26 @@
+package reachable
+
+import "time"
+
+// remindDown fires OnDownReminder when DownReminderInterval has passed since
+// the down notification or the previous reminder.
+func (c *Checker) remindDown() {
+	if c.currentStatus != 0 {
+		c.downSince = time.Time{}
+		return
+	}
+	now := c.clock()
+	if c.downSince.IsZero() {
+		c.downSince = now
+		c.lastReminder = now
+		return
+	}
+	if c.DownReminderInterval <= 0 || now.Sub(c.lastReminder) < c.DownReminderInterval {
+		return
+	}
+	c.lastReminder = now
+	if c.OnDownReminder != nil {
+		since := c.downSince
+		c.dispatch(func() { c.OnDownReminder(since) })
+	}
+}
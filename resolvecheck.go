@@ -0,0 +1,65 @@
+package reachable
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// unexpectedAddrError reports a resolved address outside ExpectResolvesTo.
+type unexpectedAddrError struct {
+	host, addr string
+	expect     []string
+}
+
+func (e *unexpectedAddrError) Error() string {
+	return fmt.Sprintf("reachable: %s resolved to %s, want %s", e.host, e.addr, strings.Join(e.expect, " or "))
+}
+
+// checkResolved returns an error matching ErrUnexpectedAddress unless every
+// one of the addresses host resolved to is within ExpectResolvesTo.
+func (c *Checker) checkResolved(host string, addrs []string) error {
+	if len(c.ExpectResolvesTo) == 0 {
+		return nil
+	}
+	nets, err := parseExpected(c.ExpectResolvesTo)
+	if err != nil {
+		return &classError{ErrConfig, err}
+	}
+	for _, a := range addrs {
+		ip := net.ParseIP(a)
+		ok := false
+		for _, n := range nets {
+			if ip != nil && n.Contains(ip) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return &classError{ErrUnexpectedAddress, &unexpectedAddrError{host, a, c.ExpectResolvesTo}}
+		}
+	}
+	return nil
+}
+
+// parseExpected parses ExpectResolvesTo entries, each an IP address or a
+// CIDR block.
+func parseExpected(expect []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(expect))
+	for _, s := range expect {
+		if ip := net.ParseIP(s); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("reachable: ExpectResolvesTo entry %q is not an IP address or CIDR block", s)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
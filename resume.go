@@ -0,0 +1,41 @@
+package reachable
+
+import (
+	"context"
+	"time"
+)
+
+// ResumeSupported reports whether Checker.CheckOnResume works on this
+// platform.
+const ResumeSupported = resumeSupported
+
+var (
+	// resumePoll is how often the clocks are compared to detect a resume.
+	resumePoll = time.Second * 5
+
+	// resumeSlack is how far the wall clock may run ahead of the monotonic
+	// clock in one poll before it is taken as a suspend.
+	resumeSlack = time.Second * 5
+)
+
+// watchResume calls CheckNow whenever the system appears to have resumed from
+// suspend, until ctx is done. A suspended system stops the monotonic clock
+// but not the wall clock, so a resume shows up as the wall clock jumping ahead
+// of the monotonic one.
+func (c *Checker) watchResume(ctx context.Context) {
+	t := time.NewTicker(resumePoll)
+	defer t.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			wall := now.Round(0).Sub(last.Round(0))
+			if wall-now.Sub(last) > resumeSlack {
+				c.CheckNow()
+			}
+			last = now
+		}
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !linux && !darwin
+
+package reachable
+
+const resumeSupported = false
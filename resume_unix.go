@@ -0,0 +1,7 @@
+//go:build linux || darwin
+
+package reachable
+
+// On Linux and macOS the monotonic clock used by package time does not
+// advance while the system is suspended.
+const resumeSupported = true
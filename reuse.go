@@ -0,0 +1,80 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// DefaultReuseMaxAge is how long a reused probe connection is trusted before
+// a fresh dial is made, when Checker.ReuseMaxAge is unset.
+var DefaultReuseMaxAge = time.Minute * 10
+
+// heldConn is a probe connection held open with ReuseConn.
+type heldConn struct {
+	conn    net.Conn
+	created time.Time
+}
+
+// probeReused checks hostport using a held-open connection when one exists
+// and still looks healthy, and otherwise dials a new one and keeps it.
+func (c *Checker) probeReused(ctx context.Context, hostport string, res *result) error {
+	c.mu.Lock()
+	pc := c.held[hostport]
+	delete(c.held, hostport)
+	c.mu.Unlock()
+
+	if pc != nil {
+		if time.Since(pc.created) < orDefault(c.ReuseMaxAge, DefaultReuseMaxAge) && connAlive(pc.conn) {
+			res.target = hostport
+			res.addr = pc.conn.RemoteAddr()
+			c.keepConn(hostport, pc)
+			return nil
+		}
+		pc.conn.Close()
+	}
+
+	conn, err := c.dial(ctx, res, net.Dialer{KeepAlive: c.Interval}, c.network(), hostport)
+	if err != nil {
+		return err
+	}
+	res.addr = conn.RemoteAddr()
+	if err := c.sendProxyHeader(ctx, conn); err != nil {
+		conn.Close()
+		return err
+	}
+	c.keepConn(hostport, &heldConn{conn: conn, created: time.Now()})
+	return nil
+}
+
+func (c *Checker) keepConn(hostport string, pc *heldConn) {
+	c.mu.Lock()
+	if c.held == nil {
+		c.held = make(map[string]*heldConn)
+	}
+	c.held[hostport] = pc
+	c.mu.Unlock()
+}
+
+// closeHeld closes all connections held by ReuseConn.
+func (c *Checker) closeHeld() {
+	c.mu.Lock()
+	for hp, pc := range c.held {
+		pc.conn.Close()
+		delete(c.held, hp)
+	}
+	c.mu.Unlock()
+}
+
+// connAlive makes a very short read to tell whether the peer has closed or
+// reset conn. A read timeout means nothing is wrong as far as the local
+// kernel knows; TCP keepalives detect a dead path underneath.
+func connAlive(conn net.Conn) bool {
+	var buf [1]byte
+	conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+	_, err := conn.Read(buf[:])
+	conn.SetReadDeadline(time.Time{})
+	var ne net.Error
+	return err == nil || (errors.As(err, &ne) && ne.Timeout())
+}
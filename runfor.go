@@ -0,0 +1,21 @@
+package reachable
+
+import (
+	"context"
+	"time"
+)
+
+// expireAfter stops the Checker once RunFor has elapsed, unless ctx is done
+// first, and then calls OnExpire.
+func (c *Checker) expireAfter(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-t.C:
+	}
+	if c.stop() && c.OnExpire != nil {
+		c.dispatch(c.OnExpire)
+	}
+}
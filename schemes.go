@@ -0,0 +1,104 @@
+package reachable
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ProbeFunc probes the service named by a URL with a scheme registered with
+// RegisterScheme. It should give up when ctx is done, and return nil if the
+// service is reachable. Its errors are classified like the package's own, so
+// a *net.OpError for a refused connection matches ErrRefused.
+type ProbeFunc func(ctx context.Context, u *url.URL) error
+
+// schemes are the URL schemes registered with RegisterScheme and
+// RegisterTCPScheme, in addition to the built-in http, https, tcp and tls.
+var schemes struct {
+	mu     sync.RWMutex
+	probes map[string]ProbeFunc
+	ports  map[string]string
+}
+
+func init() {
+	RegisterTCPScheme("redis", 6379)
+	RegisterTCPScheme("postgres", 5432)
+	RegisterTCPScheme("postgresql", 5432)
+	RegisterTCPScheme("mysql", 3306)
+}
+
+// RegisterScheme makes Hostport URLs with the given scheme, such as
+// "amqp://broker/", be checked by probe, for services the built-in probes do
+// not cover. It can also replace the built-in http, https, tcp or tls probe.
+// A nil probe removes the registration, restoring any built-in probe.
+// Hostport URLs with a scheme that is neither built in nor registered fail
+// with ErrConfig. It is safe to call while Checkers are running.
+func RegisterScheme(scheme string, probe ProbeFunc) {
+	scheme = strings.ToLower(scheme)
+	schemes.mu.Lock()
+	defer schemes.mu.Unlock()
+	delete(schemes.ports, scheme)
+	if probe == nil {
+		delete(schemes.probes, scheme)
+		return
+	}
+	if schemes.probes == nil {
+		schemes.probes = make(map[string]ProbeFunc)
+	}
+	schemes.probes[scheme] = probe
+}
+
+// RegisterTCPScheme makes Hostport URLs with the given scheme be checked
+// with a plain TCP connect, like tcp://, to port unless the URL has a port of
+// its own. The redis, postgres, postgresql and mysql schemes are registered
+// this way with their standard ports, so that an application's connection
+// URL can be used as is.
+func RegisterTCPScheme(scheme string, port int) {
+	scheme = strings.ToLower(scheme)
+	schemes.mu.Lock()
+	defer schemes.mu.Unlock()
+	delete(schemes.probes, scheme)
+	if schemes.ports == nil {
+		schemes.ports = make(map[string]string)
+	}
+	schemes.ports[scheme] = strconv.Itoa(port)
+}
+
+// registeredScheme returns the probe or the default TCP port registered for
+// scheme, and whether there is either.
+func registeredScheme(scheme string) (ProbeFunc, string, bool) {
+	schemes.mu.RLock()
+	defer schemes.mu.RUnlock()
+	if probe, ok := schemes.probes[scheme]; ok {
+		return probe, "", true
+	}
+	port, ok := schemes.ports[scheme]
+	return nil, port, ok
+}
+
+// builtinScheme reports whether scheme has a built-in probe.
+func builtinScheme(scheme string) bool {
+	switch scheme {
+	case "http", "https", "tcp", "tls":
+		return true
+	}
+	return false
+}
+
+// probeScheme probes u with its registered scheme.
+func (c *Checker) probeScheme(ctx context.Context, u *url.URL, probe ProbeFunc, port string, res *result) error {
+	if probe == nil {
+		if u.Port() != "" {
+			port = u.Port()
+		}
+		return c.probeHost(ctx, net.JoinHostPort(u.Hostname(), port), res)
+	}
+	if err := probe(ctx, u); err != nil {
+		return fmt.Errorf("reachable: %s probe of %s: %w", u.Scheme, u.Redacted(), err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Package sdnotify reports a reachable.Checker's state to systemd through the
+// sd_notify protocol, for services run with Type=notify and an optional
+// WatchdogSec. The status line shown by systemctl status follows
+// reachability, and watchdog pings are only sent while the host is
+// reachable, so systemd restarts a service that has lost connectivity for
+// longer than WatchdogSec.
+//
+//    n := &sdnotify.Notifier{Name: "db", Ready: true}
+//    defer n.Close()
+//    c := reachable.Checker{
+//        Hostport: "db:5432",
+//        Notifier: n.Notify,
+//    }
+//    c.Start()
+//
+// The protocol is only spoken on Linux, and only when systemd has set
+// NOTIFY_SOCKET; otherwise the Notifier does nothing, so it is safe to use
+// unconditionally.
+package sdnotify
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Notifier sends reachability changes to systemd. Its Notify method can be
+// used directly as a reachable.Checker Notifier.
+type Notifier struct {
+	// Name identifies the host in the status line, e.g. "db unreachable".
+	// If empty, the line is just "reachable" or "unreachable".
+	Name string
+
+	// Ready sends READY=1 the first time the host is reachable, for a
+	// service whose startup should only complete once it has connectivity.
+	Ready bool
+
+	// ErrorLog, if set, is called when a message could not be sent.
+	ErrorLog func(error)
+
+	mu        sync.Mutex
+	up        bool
+	readySent bool
+	watchdog  bool
+	done      chan struct{}
+}
+
+// Notify updates the status line, sends READY=1 if requested, and starts or
+// pauses watchdog pings. It has the signature of a reachable.Checker
+// Notifier.
+func (n *Notifier) Notify(reachable bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.up = reachable
+
+	status := "unreachable"
+	if reachable {
+		status = "reachable"
+	}
+	if n.Name != "" {
+		status = n.Name + " " + status
+	}
+	msg := "STATUS=" + status
+	if reachable && n.Ready && !n.readySent {
+		n.readySent = true
+		msg = "READY=1\n" + msg
+	}
+	n.send(msg)
+
+	if !n.watchdog {
+		n.watchdog = true
+		if d := WatchdogInterval(); d > 0 {
+			n.done = make(chan struct{})
+			go n.ping(d/2, n.done)
+		}
+	}
+}
+
+// Close stops the watchdog pings. The Notifier must not be used afterwards.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.done != nil {
+		close(n.done)
+		n.done = nil
+	}
+}
+
+// ping sends WATCHDOG=1 every interval while the host is reachable.
+func (n *Notifier) ping(interval time.Duration, done chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			n.mu.Lock()
+			if n.up {
+				n.send("WATCHDOG=1")
+			}
+			n.mu.Unlock()
+		}
+	}
+}
+
+func (n *Notifier) send(msg string) {
+	if err := Send(msg); err != nil && n.ErrorLog != nil {
+		n.ErrorLog(err)
+	}
+}
+
+// WatchdogInterval returns the watchdog timeout systemd set for this process,
+// or zero if the watchdog is not enabled for it.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
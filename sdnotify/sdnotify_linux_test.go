@@ -0,0 +1,82 @@
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// socket listens on a NOTIFY_SOCKET for the test, and returns a function
+// reading the next message, or "" if none arrives within wait.
+func socket(t *testing.T) func(wait time.Duration) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return func(wait time.Duration) string {
+		buf := make([]byte, 512)
+		conn.SetReadDeadline(time.Now().Add(wait))
+		n, err := conn.Read(buf)
+		if err != nil {
+			return ""
+		}
+		return string(buf[:n])
+	}
+}
+
+func TestNotify(t *testing.T) {
+	next := socket(t)
+	t.Setenv("WATCHDOG_USEC", "100000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+
+	n := &Notifier{Name: "db", Ready: true, ErrorLog: func(err error) { t.Error(err) }}
+	defer n.Close()
+	n.Notify(true)
+	if got := next(time.Second); got != "READY=1\nSTATUS=db reachable" {
+		t.Errorf("first message %q, want READY=1 and the reachable status", got)
+	}
+	if got := next(time.Second); got != "WATCHDOG=1" {
+		t.Errorf("got %q while reachable, want a watchdog ping", got)
+	}
+
+	n.Notify(false)
+	for got := next(time.Second); got != "STATUS=db unreachable"; got = next(time.Second) {
+		if got != "WATCHDOG=1" {
+			t.Fatalf("got %q, want the unreachable status", got)
+		}
+	}
+	if got := next(200 * time.Millisecond); got != "" {
+		t.Errorf("got %q while unreachable, want no watchdog pings", got)
+	}
+
+	n.Notify(true)
+	if got := next(time.Second); got != "STATUS=db reachable" {
+		t.Errorf("got %q, want the reachable status without READY=1 again", got)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "2000000")
+	t.Setenv("WATCHDOG_PID", "")
+	if d := WatchdogInterval(); d != 2*time.Second {
+		t.Errorf("WatchdogInterval() = %v, want 2s", d)
+	}
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	if d := WatchdogInterval(); d != 0 {
+		t.Errorf("WatchdogInterval() = %v for another process, want 0", d)
+	}
+}
+
+func TestSendWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := Send("STATUS=x"); err != nil {
+		t.Errorf("Send() = %v without NOTIFY_SOCKET, want nil", err)
+	}
+}
@@ -0,0 +1,27 @@
+package sdnotify
+
+import (
+	"net"
+	"os"
+)
+
+// Send sends a raw sd_notify message, such as "STATUS=..." or several
+// newline-separated assignments, to the socket in NOTIFY_SOCKET. It does
+// nothing and returns nil if NOTIFY_SOCKET is not set.
+func Send(state string) error {
+	path := os.Getenv("NOTIFY_SOCKET")
+	if path == "" {
+		return nil
+	}
+	if path[0] == '@' {
+		// abstract namespace socket
+		path = "\x00" + path[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package sdnotify
+
+// Send does nothing and returns nil: sd_notify is only spoken on Linux.
+func Send(state string) error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package reachable
+
+import "time"
+
+// TimeToFirstCheck returns how long after Start the first check completed,
+// and whether it has yet. Together with TimeToFirstReachable it shows where
+// startup time goes: a long time to the first check points to a slow probe,
+// and a long gap between the two to a dependency that was not yet available.
+func (c *Checker) TimeToFirstCheck() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return since(c.startedAt, c.firstCheckAt)
+}
+
+// TimeToFirstReachable returns how long after Start the host was first found
+// reachable, and whether it has been yet, for measuring how long a dependency
+// takes to become available at boot. It is measured on the system clock,
+// like latencies, and is reset by each Start.
+func (c *Checker) TimeToFirstReachable() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return since(c.startedAt, c.firstReachableAt)
+}
+
+// since returns the time from start to t, and whether both are set.
+func since(start, t time.Time) (time.Duration, bool) {
+	if start.IsZero() || t.IsZero() {
+		return 0, false
+	}
+	return t.Sub(start), true
+}
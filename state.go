@@ -0,0 +1,86 @@
+package reachable
+
+import (
+	"fmt"
+	"time"
+)
+
+// State describes the reachability of a host.
+type State int
+
+const (
+	// Unknown means no check has completed yet.
+	Unknown State = iota
+
+	// Up means the host was reachable on the last check.
+	Up
+
+	// Down means the host was not reachable on the last check.
+	Down
+
+	// Degraded means the host is reachable but its smoothed latency is above
+	// the Checker's DegradedThreshold.
+	Degraded
+
+	// CaptivePortal means requests are being intercepted, typically by a
+	// Wi-Fi sign-in page, so the internet is not really reachable. It is
+	// only reported with Checker.CaptivePortal set.
+	CaptivePortal
+)
+
+func (s State) String() string {
+	switch s {
+	case Up:
+		return "up"
+	case Down:
+		return "down"
+	case Degraded:
+		return "degraded"
+	case CaptivePortal:
+		return "captive-portal"
+	}
+	return "unknown"
+}
+
+// reachable reports whether s means the host can be reached.
+func (s State) reachable() bool {
+	return s == Up || s == Degraded
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding a State as its
+// String form.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *State) UnmarshalText(text []byte) error {
+	for _, x := range []State{Unknown, Up, Down, Degraded, CaptivePortal} {
+		if string(text) == x.String() {
+			*s = x
+			return nil
+		}
+	}
+	return fmt.Errorf("reachable: unknown state %q", text)
+}
+
+var (
+	// forced is reported by NetworkIsReachable until forcedUntil.
+	forced      State
+	forcedUntil time.Time
+)
+
+// ForceState overrides the state reported by NetworkIsReachable for duration
+// d, after which the real result of the background checks is reported again.
+// Checks keep running while the override is active. Calling ForceState with
+// Unknown or a non-positive duration clears any active override.
+//
+// This is a testing and operations tool, intended for simulating outages when
+// exercising an application's offline behavior. It is safe to call while the
+// default Checker is running.
+func ForceState(s State, d time.Duration) {
+	smu.Lock()
+	forced = s
+	forcedUntil = time.Now().Add(d)
+	smu.Unlock()
+}
@@ -0,0 +1,56 @@
+package reachable
+
+import "time"
+
+// Stats are cumulative counters for a Checker since it was last started.
+type Stats struct {
+	// Checks is the number of checks performed, and Failures how many of
+	// those found the host unreachable.
+	Checks   int
+	Failures int
+
+	// Streak is the number of consecutive checks, up to and including the
+	// most recent, that had the same result.
+	Streak int
+
+	// LastLatency is the duration of the most recent successful probe, and
+	// AvgLatency the mean over all successful probes.
+	LastLatency time.Duration
+	AvgLatency  time.Duration
+
+	// EstimatedBytes is the estimated data used by the checks, and
+	// BudgetSkips the number of checks skipped to stay within
+	// Checker.MaxBytesPerHour.
+	EstimatedBytes int64
+	BudgetSkips    int
+
+	lastOK       bool
+	successes    int
+	totalLatency time.Duration
+}
+
+// Stats returns the Checker's counters since Start was called. It is safe to
+// call while the Checker is running.
+func (c *Checker) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func (s *Stats) add(res result) {
+	if s.Checks == 0 || res.ok != s.lastOK {
+		s.Streak = 0
+	}
+	s.Checks++
+	s.EstimatedBytes += res.bytes
+	s.Streak++
+	s.lastOK = res.ok
+	if !res.ok {
+		s.Failures++
+		return
+	}
+	s.successes++
+	s.totalLatency += res.latency
+	s.LastLatency = res.latency
+	s.AvgLatency = s.totalLatency / time.Duration(s.successes)
+}
@@ -0,0 +1,169 @@
+package reachable
+
+import "time"
+
+// Status is a point-in-time snapshot of a Checker's observations.
+// It is JSON-serializable for exposing over an API.
+type Status struct {
+	// Name is the Checker's Name, or its hosts if unnamed.
+	Name string `json:"name"`
+
+	// Tags is a copy of the Checker's Tags.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// State is the result of the most recent check.
+	State State `json:"state"`
+
+	// Reachable is the most recently notified reachability.
+	Reachable bool `json:"reachable"`
+
+	// InterfaceUp is the result of the local interface check made by the
+	// most recent check, separately from whether the host was reached. It
+	// distinguishes "my link dropped" (false) from "server unreachable"
+	// (true, but State is Down). It is always true when the interface check
+	// is off (see Checker.InterfaceGate). LastInterfaceUp is the last check
+	// that found an interface up.
+	InterfaceUp     bool      `json:"interfaceUp"`
+	LastInterfaceUp time.Time `json:"lastInterfaceUp"`
+
+	// Interface is the name of the network interface that satisfied the
+	// interface check in the most recent check. It is the first suitable
+	// interface found, which is not necessarily the one the probe was routed
+	// over. It is empty if the check was skipped or failed.
+	Interface string `json:"interface,omitempty"`
+
+	// Metered is the result of Checker.IsMetered for Interface.
+	Metered bool `json:"metered,omitempty"`
+
+	// Host is the hostport probed by the most recent check. With
+	// Checker.Hostports it shows which entry decided the result.
+	Host string `json:"host,omitempty"`
+
+	// Ports are the per-port results of the most recent check when
+	// Checker.Ports is set.
+	Ports []PortStatus `json:"ports,omitempty"`
+
+	// Uplinks are the per-interface results of the most recent check when
+	// Checker.Interfaces is set.
+	Uplinks []UplinkStatus `json:"uplinks,omitempty"`
+
+	// Hosts are the per-host results of the most recent check when
+	// Checker.Quorum is set.
+	Hosts []HostStatus `json:"hosts,omitempty"`
+
+	// DialTarget is the exact address passed to the dialer by the most
+	// recent check, after default ports, IPv6 bracketing and any separate
+	// DNS resolution, whether or not the dial succeeded. For HTTP probes it
+	// is the URL's host and port. Together with Addr this shows what was
+	// actually contacted.
+	DialTarget string `json:"dialTarget,omitempty"`
+
+	// Resolved are the addresses from the most recent DNS lookup made as a
+	// separate step of a probe (see Checker.ResolveTimeout, Network and
+	// RetryFreshDNS).
+	Resolved []string `json:"resolved,omitempty"`
+
+	// UnexpectedAddr is the first resolved address outside
+	// Checker.ExpectResolvesTo in the most recent check, or empty if there
+	// was none. Err then matches ErrUnexpectedAddress.
+	UnexpectedAddr string `json:"unexpectedAddr,omitempty"`
+
+	// Addr is the remote address reached by the most recent successful
+	// probe, as reported by the connection, if known. With Checker.Network
+	// set it shows which A or AAAA record was used.
+	Addr string `json:"addr,omitempty"`
+
+	// Family is the address family, "tcp4" or "tcp6", that connected first
+	// in the most recent successful probe with Checker.HappyEyeballs set.
+	Family string `json:"family,omitempty"`
+
+	// ALPN is the application protocol, such as "h2", negotiated by the
+	// most recent successful tls:// probe, or empty if none was.
+	ALPN string `json:"alpn,omitempty"`
+
+	// TLSFailed is true when the most recent check connected to the host
+	// but failed the TLS handshake of a tls:// or https:// probe, telling
+	// broken SSL interception apart from a host that cannot be reached at
+	// all. Err then matches ErrTLSHandshake.
+	TLSFailed bool `json:"tlsFailed,omitempty"`
+
+	// Health is the response of the health endpoint in the most recent
+	// check with Checker.HealthJSON set, if it returned one.
+	Health *Health `json:"health,omitempty"`
+
+	// Err is the error from the most recent check, or nil if it succeeded.
+	// It can be matched against the package's Err values with errors.Is, and
+	// may be set on a reachable host when ServiceDown is true. Error holds the
+	// same error as a string.
+	Err   error  `json:"-"`
+	Error string `json:"error,omitempty"`
+
+	// ServiceDown is true when the most recent check reached the network
+	// path but the service refused the connection. It is only set when
+	// Checker.RefusedIsReachable is enabled.
+	ServiceDown bool `json:"serviceDown,omitempty"`
+
+	// DownCause is why the host is Down: local link loss, DNS failure,
+	// timeout, refused connection, or CauseOther. It is CauseOther whenever
+	// State is not Down.
+	DownCause Cause `json:"downCause"`
+
+	// Latency is the exponentially weighted moving average of successful
+	// probe latencies. See Checker.LatencySmoothing.
+	Latency time.Duration `json:"latency"`
+
+	// DNSTime, ConnectTime and TLSTime break down where the most recent
+	// probe spent its time, whether or not it succeeded. DNSTime is only
+	// measured when resolution is a separate step (see
+	// Checker.ResolveTimeout, Checker.Network and Checker.HappyEyeballs) and
+	// for HTTP probes; otherwise ConnectTime includes the lookup. TLSTime is
+	// only set for tls:// and https:// probes. Phases that were not reached
+	// are zero.
+	DNSTime     time.Duration `json:"dnsTime,omitempty"`
+	ConnectTime time.Duration `json:"connectTime,omitempty"`
+	TLSTime     time.Duration `json:"tlsTime,omitempty"`
+
+	// LastCheck is when the most recent check completed. It is zero until
+	// the first check completes.
+	LastCheck time.Time `json:"lastCheck"`
+
+	// LastChange is when Reachable last changed. It is zero until the first
+	// check completes.
+	LastChange time.Time `json:"lastChange"`
+
+	// LastSuccess is when the host was last reached, and LastFailure is when a
+	// check last failed. Either may be zero if it has not happened yet. During
+	// a prolonged outage LastSuccess shows how stale the last good check is.
+	LastSuccess time.Time `json:"lastSuccess"`
+	LastFailure time.Time `json:"lastFailure"`
+
+	// ConsecutiveCount is the number of consecutive checks, up to and
+	// including the most recent, with the same result: consecutive failures
+	// while down, consecutive successes while up. It resets to 1 when the
+	// result flips, and can be read from within a Notifier to escalate long
+	// outages differently from brief ones.
+	ConsecutiveCount int `json:"consecutiveCount"`
+}
+
+// copyTags copies tags, preserving nil.
+func copyTags(tags map[string]string) map[string]string {
+	if tags == nil {
+		return nil
+	}
+	cp := make(map[string]string, len(tags))
+	for k, v := range tags {
+		cp[k] = v
+	}
+	return cp
+}
+
+// Status returns a snapshot of the Checker's current state. It is safe to call
+// while the Checker is running.
+func (c *Checker) Status() Status {
+	c.mu.Lock()
+	st := c.status
+	c.mu.Unlock()
+	st.Name = c.name()
+	st.Tags = copyTags(c.Tags)
+	return st
+}
@@ -0,0 +1,62 @@
+package reachable
+
+import (
+	"fmt"
+	"time"
+)
+
+// ANSI escape sequences used by StatusLineColor.
+const (
+	ansiReset     = "\x1b[0m"
+	ansiClearLine = "\x1b[K"
+)
+
+var stateColors = map[State]string{
+	Up:            "\x1b[32m", // green
+	Degraded:      "\x1b[33m", // yellow
+	Down:          "\x1b[31m", // red
+	CaptivePortal: "\x1b[35m", // magenta
+}
+
+// StatusLine returns a one-line summary of the current Status for terminal
+// UIs: the Checker's name, its State, the smoothed latency and how long
+// Reachable has had its current value, e.g.
+//
+//    google.com  up             23ms  for 5m12s
+//
+// A Down host shows its DownCause in place of the latency. The fields are
+// padded to fixed widths so that a line can be overwritten with the next one
+// by printing "\r" before it.
+func (c *Checker) StatusLine() string {
+	return c.statusLine(false)
+}
+
+// StatusLineColor is like StatusLine, but colors the State with ANSI escape
+// sequences and ends by clearing the rest of the terminal line.
+func (c *Checker) StatusLineColor() string {
+	return c.statusLine(true)
+}
+
+func (c *Checker) statusLine(color bool) string {
+	st := c.Status()
+	detail := "-"
+	switch {
+	case st.State == Down:
+		detail = st.DownCause.String()
+	case st.Latency > 0:
+		detail = st.Latency.Round(time.Millisecond).String()
+	}
+	since := "-"
+	if !st.LastChange.IsZero() {
+		since = c.clock().Sub(st.LastChange).Round(time.Second).String()
+	}
+	state := fmt.Sprintf("%-8s", st.State)
+	if code, ok := stateColors[st.State]; color && ok {
+		state = code + state + ansiReset
+	}
+	line := fmt.Sprintf("%s  %s %10s  for %-10s", st.Name, state, detail, since)
+	if color {
+		line += ansiClearLine
+	}
+	return line
+}
@@ -0,0 +1,55 @@
+package reachable
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// probeTLS connects to the host of u, 443 by default, or to DialHost, and
+// completes a TLS handshake, checking ExpectALPN, before finishing the probe
+// over the encrypted connection.
+func (c *Checker) probeTLS(ctx context.Context, u *url.URL, res *result) error {
+	conn, err := c.connect(ctx, c.dialAddr(urlHostport(u)), res)
+	if err != nil {
+		return err
+	}
+
+	cfg := &tls.Config{}
+	if c.TLSConfig != nil {
+		cfg = c.TLSConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = u.Hostname()
+	}
+	if c.ExpectALPN != "" && len(cfg.NextProtos) == 0 {
+		cfg.NextProtos = []string{c.ExpectALPN}
+	}
+	tconn := tls.Client(conn, cfg)
+	start := time.Now()
+	err = tconn.HandshakeContext(ctx)
+	res.tlsTime = time.Since(start)
+	if err != nil {
+		conn.Close()
+		// a server supporting none of the offered protocols aborts the
+		// handshake with an alert, which crypto/tls does not export
+		if c.ExpectALPN != "" && strings.Contains(err.Error(), "no application protocol") {
+			return &classError{ErrALPN, err}
+		}
+		return &classError{ErrTLSHandshake, err}
+	}
+	res.alpn = tconn.ConnectionState().NegotiatedProtocol
+	if c.ExpectALPN != "" && res.alpn != c.ExpectALPN {
+		conn.Close()
+		got := res.alpn
+		if got == "" {
+			got = "none"
+		}
+		return &classError{ErrALPN, fmt.Errorf("reachable: %s negotiated protocol %s, want %s",
+			u.Host, got, c.ExpectALPN)}
+	}
+	return c.finish(ctx, tconn, res)
+}
@@ -0,0 +1,120 @@
+package reachable
+
+import (
+	"sync"
+	"time"
+)
+
+// Trace is the complete detail of a single check, passed to Checker.OnTrace.
+type Trace struct {
+	// Start is when the check began, and Latency how long its probe took.
+	Start   time.Time
+	Latency time.Duration
+
+	// InterfaceUp and Interface are the result of the interface check, as
+	// in Status.
+	InterfaceUp bool
+	Interface   string
+
+	// Host, DialTarget, Resolved, Addr, Family and ALPN are as in Status,
+	// for this check alone.
+	Host       string
+	DialTarget string
+	Resolved   []string
+	Addr       string
+	Family     string
+	ALPN       string
+
+	// DNSTime, ConnectTime and TLSTime are as in Status.
+	DNSTime     time.Duration
+	ConnectTime time.Duration
+	TLSTime     time.Duration
+
+	// Dials lists every connection attempt the probe made, in the order
+	// they finished, including those to each resolved address in turn and
+	// those of HTTP probes.
+	Dials []DialAttempt
+
+	// Ports, Uplinks and Hosts are as in Status.
+	Ports   []PortStatus
+	Uplinks []UplinkStatus
+	Hosts   []HostStatus
+
+	// Reachable and State are the outcome of the check after settings such
+	// as StickyDuration and ConfirmUpAfter are applied, and Err and Cause
+	// its classified error, if any.
+	Reachable bool
+	State     State
+	Err       error
+	Cause     Cause
+}
+
+// DialAttempt is one connection attempt in a Trace.
+type DialAttempt struct {
+	Network string
+	Addr    string
+	Time    time.Duration
+	Err     error
+}
+
+// dialLog collects the dial attempts of a check for OnTrace. The dials of an
+// HTTP probe or Happy Eyeballs race are made concurrently, and may finish
+// after the check, so it is shared by pointer and guarded by its own mutex.
+type dialLog struct {
+	mu    sync.Mutex
+	dials []DialAttempt
+}
+
+// add records a dial attempt, if l is not nil.
+func (l *dialLog) add(network, addr string, took time.Duration, err error) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.dials = append(l.dials, DialAttempt{Network: network, Addr: addr, Time: took, Err: classify(err)})
+	l.mu.Unlock()
+}
+
+// list returns a copy of the attempts recorded so far.
+func (l *dialLog) list() []DialAttempt {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]DialAttempt(nil), l.dials...)
+}
+
+// trace calls OnTrace with the detail of the check that produced res.
+func (c *Checker) trace(res result, start time.Time, up bool, state State) {
+	t := Trace{
+		Start:       start,
+		Latency:     res.latency,
+		InterfaceUp: res.ifaceUp,
+		Host:        res.host,
+		DialTarget:  res.target,
+		Resolved:    res.resolved,
+		Family:      res.family,
+		ALPN:        res.alpn,
+		DNSTime:     res.dnsTime,
+		ConnectTime: res.connectTime,
+		TLSTime:     res.tlsTime,
+		Dials:       res.dials.list(),
+		Ports:       res.ports,
+		Uplinks:     res.uplinks,
+		Hosts:       res.hosts,
+		Reachable:   up,
+		State:       state,
+		Err:         res.err,
+	}
+	if res.iface != nil {
+		t.Interface = res.iface.Name
+	}
+	if res.addr != nil {
+		t.Addr = res.addr.String()
+	}
+	if res.err != nil {
+		t.Cause = causeOf(res.err)
+	}
+	c.dispatch(func() { c.OnTrace(t) })
+}
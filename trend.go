@@ -0,0 +1,77 @@
+package reachable
+
+import "time"
+
+// DefaultTrendWindow is how many recent probe latencies LatencyTrend is
+// computed from when Checker.TrendWindow is unset.
+const DefaultTrendWindow = 10
+
+// minTrendSamples is how many latencies LatencyTrend needs for a slope.
+const minTrendSamples = 5
+
+// LatencyTrend returns how fast probe latency has been changing: the slope,
+// in seconds per check, of a least-squares line through the latencies of the
+// last TrendWindow successful probes. A positive slope means latency is
+// rising, e.g. 0.005 for 5ms more each check, which can warn of an outage
+// before it happens. Failed probes are left out. It is 0 until at least 5
+// successful probes have been made since Start, so that a couple of noisy
+// samples do not make a trend.
+func (c *Checker) LatencyTrend() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.latencyTrend()
+}
+
+// latencyTrend computes LatencyTrend. c.mu must be held.
+func (c *Checker) latencyTrend() float64 {
+	n := len(c.latencies)
+	if n < minTrendSamples {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, d := range c.latencies {
+		x, y := float64(i), d.Seconds()
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	fn := float64(n)
+	return (fn*sumXY - sumX*sumY) / (fn*sumXX - sumX*sumX)
+}
+
+// addLatency records a successful probe's latency for LatencyTrend. c.mu
+// must be held.
+func (c *Checker) addLatency(d time.Duration) {
+	n := c.TrendWindow
+	if n <= 0 {
+		n = DefaultTrendWindow
+	}
+	if c.latencies == nil {
+		c.latencies = make([]time.Duration, 0, n)
+	}
+	if len(c.latencies) >= n {
+		copy(c.latencies, c.latencies[len(c.latencies)-n+1:])
+		c.latencies = c.latencies[:n-1]
+	}
+	c.latencies = append(c.latencies, d)
+}
+
+// checkTrend calls OnDegrading when LatencyTrend rises above DegradingSlope,
+// once until it falls back to or below it.
+func (c *Checker) checkTrend() {
+	if c.OnDegrading == nil || c.DegradingSlope <= 0 {
+		return
+	}
+	c.mu.Lock()
+	slope := c.latencyTrend()
+	c.mu.Unlock()
+	if slope <= c.DegradingSlope {
+		c.degrading = false
+		return
+	}
+	if !c.degrading {
+		c.degrading = true
+		c.dispatch(func() { c.OnDegrading(slope) })
+	}
+}
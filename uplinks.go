@@ -0,0 +1,128 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// UplinkStatus is the result of checking through a single interface when
+// Checker.Interfaces is set.
+type UplinkStatus struct {
+	Interface string        `json:"interface"`
+	Source    string        `json:"source,omitempty"`
+	Reachable bool          `json:"reachable"`
+	Latency   time.Duration `json:"latency"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// probeUplinks runs the probe once through each of Interfaces concurrently,
+// with connections sourced from that interface's address, and succeeds if
+// any of them does.
+func (c *Checker) probeUplinks(ctx context.Context, res *result) error {
+	uplinks := make([]UplinkStatus, len(c.Interfaces))
+	results := make([]result, len(c.Interfaces))
+	errs := make([]error, len(c.Interfaces))
+	var wg sync.WaitGroup
+	for i, name := range c.Interfaces {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			uplinks[i].Interface = name
+			src, err := c.sourceAddr(name)
+			if err == nil {
+				uplinks[i].Source = src.String()
+				results[i].source = src
+				results[i].dials = res.dials
+				start := time.Now()
+				err = c.probeTargets(ctx, &results[i])
+				uplinks[i].Latency = time.Since(start)
+			}
+			uplinks[i].Reachable = err == nil
+			if err != nil {
+				uplinks[i].Error = err.Error()
+				errs[i] = fmt.Errorf("interface %s: %w", name, err)
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	res.uplinks = uplinks
+	// report the details of the first working uplink, or the first one
+	best := 0
+	for i, err := range errs {
+		if err == nil {
+			best = i
+			break
+		}
+	}
+	r := results[best]
+	res.host, res.target, res.addr = r.host, r.target, r.addr
+	res.resolved, res.family, res.ports = r.resolved, r.family, r.ports
+	res.largeErr = r.largeErr
+	res.health, res.healthWarn = r.health, r.healthWarn
+	res.hosts = r.hosts
+	return errs[best]
+}
+
+// sourceAddr returns the address of the named interface to source probes
+// from: an IPv4 address unless Network is "tcp6", in which case a global
+// IPv6 address.
+func (c *Checker) sourceAddr(name string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if iface.Flags&net.FlagUp == 0 {
+		return nil, ErrNoInterface
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	want6 := c.network() == "tcp6"
+	for _, a := range addrs {
+		ipn, ok := a.(*net.IPNet)
+		if !ok || ipn.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if (ipn.IP.To4() == nil) == want6 {
+			return ipn.IP, nil
+		}
+	}
+	return nil, ErrNoInterface
+}
+
+// InterfaceReachable makes a single probe of hostport through the named
+// interface, with the connection sourced from its address in the same way as
+// Checker.Interfaces, and reports whether it succeeded and the probe latency.
+// It needs no running Checker, for one-off multi-WAN diagnostics. If ctx has
+// no deadline the probe times out after DefaultTimeout. An error matching
+// ErrNoInterface means the interface is down or has no usable address, and
+// ErrConfig that there is no interface by that name; otherwise the error is
+// the probe's.
+func InterfaceReachable(ctx context.Context, ifaceName, hostport string) (bool, time.Duration, error) {
+	c := &Checker{Hostport: hostport}
+	src, err := c.sourceAddr(ifaceName)
+	if errors.Is(err, ErrNoInterface) {
+		return false, 0, &classError{ErrNoInterface, fmt.Errorf("reachable: interface %s is down or has no usable address", ifaceName)}
+	} else if err != nil {
+		return false, 0, &classError{ErrConfig, fmt.Errorf("reachable: interface %s: %w", ifaceName, err)}
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout())
+		defer cancel()
+	}
+	res := result{source: src}
+	start := time.Now()
+	err = c.probeHost(ctx, hostport, &res)
+	latency := time.Since(start)
+	if err != nil {
+		return false, 0, classify(err)
+	}
+	return true, latency, nil
+}
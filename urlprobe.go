@@ -0,0 +1,258 @@
+package reachable
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// probeURL routes a URL hostport to the probe for its scheme.
+func (c *Checker) probeURL(ctx context.Context, rawurl string, res *result) error {
+	res.host = rawurl
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return &classError{ErrConfig, err}
+	}
+	if u.Host == "" {
+		return &classError{ErrConfig, fmt.Errorf("reachable: URL %q has no host", rawurl)}
+	}
+	if err := c.checkVhost(u.Scheme); err != nil {
+		return err
+	}
+	if probe, port, ok := registeredScheme(u.Scheme); ok {
+		return c.probeScheme(ctx, u, probe, port, res)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if c.HealthJSON {
+			return c.probeHealth(ctx, u, res)
+		}
+		return c.probeHTTP(ctx, u, res)
+	case "tcp":
+		return c.probeHost(ctx, u.Host, res)
+	case "tls":
+		return c.probeTLS(ctx, u, res)
+	}
+	return &classError{ErrConfig, fmt.Errorf("reachable: unsupported URL scheme %q; see RegisterScheme", u.Scheme)}
+}
+
+// probeHTTP issues a request for u, falling back from HEAD to GET if the
+// server does not allow HEAD, and checks the response status.
+func (c *Checker) probeHTTP(ctx context.Context, u *url.URL, res *result) error {
+	method := c.HTTPMethod
+	if method == "" {
+		method = http.MethodHead
+	}
+	res.target = c.dialAddr(urlHostport(u))
+	follow := !c.NoRedirects
+	status, _, err := c.httpRequest(ctx, method, u, res, follow, maxBody)
+	if err == nil && status == http.StatusMethodNotAllowed && method == http.MethodHead && !c.DisableGETFallback {
+		status, _, err = c.httpRequest(ctx, http.MethodGet, u, res, follow, maxBody)
+	}
+	if err != nil {
+		return err
+	}
+	if !c.acceptStatus(status) {
+		return &classError{ErrHTTPStatus, fmt.Errorf("reachable: %s %s returned %d %s",
+			method, u.Redacted(), status, http.StatusText(status))}
+	}
+	return nil
+}
+
+// acceptStatus reports whether an HTTP probe with the given response status
+// succeeds.
+func (c *Checker) acceptStatus(status int) bool {
+	if len(c.AcceptStatus) == 0 {
+		return status < 400
+	}
+	for _, s := range c.AcceptStatus {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// checkVhost validates DialHost and HostHeader for a probe of the given URL
+// scheme, which is empty when Hostport is not a URL.
+func (c *Checker) checkVhost(scheme string) error {
+	switch {
+	case c.HostHeader != "" && scheme != "http" && scheme != "https":
+		return &classError{ErrConfig, fmt.Errorf("reachable: HostHeader requires an http or https URL")}
+	case c.DialHost != "" && scheme != "http" && scheme != "https" && scheme != "tls":
+		return &classError{ErrConfig, fmt.Errorf("reachable: DialHost requires an http, https or tls URL")}
+	}
+	return nil
+}
+
+// urlHostport returns the host and port u connects to, filling in the
+// scheme's default port.
+func urlHostport(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "80"
+	switch u.Scheme {
+	case "https", "tls":
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// dialAddr returns the address to connect to for hostport, which is DialHost
+// if set, keeping hostport's port when DialHost has none.
+func (c *Checker) dialAddr(hostport string) string {
+	if c.DialHost == "" {
+		return hostport
+	}
+	if _, _, err := net.SplitHostPort(c.DialHost); err == nil {
+		return c.DialHost
+	}
+	_, port, _ := net.SplitHostPort(hostport)
+	return net.JoinHostPort(c.DialHost, port)
+}
+
+// Phases of an HTTP request's connection setup timed by phaseTimer.
+const (
+	phaseDNS = iota
+	phaseConnect
+	phaseTLS
+	numPhases
+)
+
+// phaseTimer times connection setup phases from httptrace callbacks, which
+// may still run on the transport's dialing goroutine after the request has
+// returned.
+type phaseTimer struct {
+	mu      sync.Mutex
+	started [numPhases]time.Time
+	took    [numPhases]time.Duration
+	tlsErr  error
+}
+
+func (p *phaseTimer) start(phase int) {
+	p.mu.Lock()
+	p.started[phase] = time.Now()
+	p.mu.Unlock()
+}
+
+func (p *phaseTimer) done(phase int) {
+	p.mu.Lock()
+	if !p.started[phase].IsZero() {
+		p.took[phase] = time.Since(p.started[phase])
+	}
+	p.mu.Unlock()
+}
+
+// tlsFailed records the error of a failed TLS handshake.
+func (p *phaseTimer) tlsFailed(err error) {
+	p.mu.Lock()
+	if err != nil {
+		p.tlsErr = err
+	}
+	p.mu.Unlock()
+}
+
+// tlsError returns the error of the last failed TLS handshake, if any.
+func (p *phaseTimer) tlsError() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tlsErr
+}
+
+// record copies the timings so far into res.
+func (p *phaseTimer) record(res *result) {
+	p.mu.Lock()
+	res.dnsTime, res.connectTime, res.tlsTime = p.took[phaseDNS], p.took[phaseConnect], p.took[phaseTLS]
+	p.mu.Unlock()
+}
+
+// maxBody is how much of a response body httpRequest usually reads.
+const maxBody = 4096
+
+// httpRequest makes a single request on a fresh connection and returns the
+// response status code and up to limit bytes of the body. Redirects are only
+// followed if follow is set.
+func (c *Checker) httpRequest(ctx context.Context, method string, u *url.URL, res *result, follow bool, limit int64) (int, []byte, error) {
+	var phases phaseTimer
+	defer phases.record(res)
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { phases.start(phaseDNS) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { phases.done(phaseDNS) },
+		ConnectStart:      func(string, string) { phases.start(phaseConnect) },
+		ConnectDone:       func(string, string, error) { phases.done(phaseConnect) },
+		TLSHandshakeStart: func() { phases.start(phaseTLS) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			phases.done(phaseTLS)
+			phases.tlsFailed(err)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			res.addr = info.Conn.RemoteAddr()
+		},
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), method, u.String(), nil)
+	if err != nil {
+		return 0, nil, &classError{ErrConfig, err}
+	}
+	if c.HostHeader != "" {
+		req.Host = c.HostHeader
+	}
+
+	// a fresh transport per probe so that every check makes a new connection
+	source, dials := res.source, res.dials
+	vhost := urlHostport(u)
+	tr := &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			// only the URL's own host is redirected to DialHost, not proxies
+			// or the targets of redirects
+			if addr == vhost {
+				addr = c.dialAddr(addr)
+			}
+			// not res, which the transport may still be dialing into after
+			// the probe returns
+			if addr == c.dialAddr(vhost) && len(c.ExpectResolvesTo) > 0 {
+				return c.resolveAndConnect(ctx, c.resolver(), addr, &result{source: source, dials: dials})
+			}
+			return c.dial(ctx, &result{source: source, dials: dials}, net.Dialer{}, network, addr)
+		},
+	}
+	if c.DialHost != "" {
+		tr.Proxy = nil
+	}
+	if c.ConnectProxy != "" {
+		proxy, err := c.connectProxy()
+		if err != nil {
+			return 0, nil, err
+		}
+		tr.Proxy = http.ProxyURL(proxy)
+	}
+	if c.TLSConfig != nil {
+		tr.TLSClientConfig = c.TLSConfig.Clone()
+	}
+	defer tr.CloseIdleConnections()
+	client := &http.Client{Transport: tr}
+	if !follow {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if phases.tlsError() != nil {
+			return 0, nil, &classError{ErrTLSHandshake, err}
+		}
+		return 0, nil, err
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, limit))
+	resp.Body.Close()
+	return resp.StatusCode, body, nil
+}
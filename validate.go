@@ -0,0 +1,153 @@
+package reachable
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ValidationError lists every problem Validate found with a Checker's
+// configuration. It matches ErrConfig with errors.Is.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "reachable: invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrConfig }
+
+// Validate checks the Checker's configuration for settings that cannot work
+// or that would be silently ignored, such as an unsupported URL scheme, a
+// MaxInterval below Interval or an inverted local port range, and returns a
+// *ValidationError listing all of them, or nil. Start validates the
+// configuration too, and if it is invalid fails every check with the
+// returned error rather than probing, so calling Validate first is only
+// needed to catch mistakes before starting.
+func (c *Checker) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	hosts := c.hostports()
+	if len(hosts) == 1 && hosts[0] == "" && c.PingFunc == nil && c.ConnFactory == nil && !c.CaptivePortal && !c.InterfaceOnly {
+		add("no Hostport or Hostports to probe")
+	}
+	tlsURL, httpURL := false, false
+	for _, hp := range hosts {
+		if !strings.Contains(hp, "://") {
+			if err := c.checkVhost(""); err != nil && hp != "" {
+				add("%s: %v", hp, strings.TrimPrefix(err.Error(), "reachable: "))
+			}
+			continue
+		}
+		u, err := url.Parse(hp)
+		switch {
+		case err != nil:
+			add("%v", err)
+			continue
+		case u.Host == "":
+			add("URL %q has no host", hp)
+		}
+		_, _, registered := registeredScheme(u.Scheme)
+		switch {
+		case registered:
+		case u.Scheme == "http", u.Scheme == "https":
+			httpURL = true
+		case u.Scheme == "tls":
+			tlsURL = true
+		case !builtinScheme(u.Scheme):
+			add("URL %q has unsupported scheme %q", u.Redacted(), u.Scheme)
+			continue
+		}
+		if err := c.checkVhost(u.Scheme); err != nil {
+			add("%s: %v", u.Redacted(), strings.TrimPrefix(err.Error(), "reachable: "))
+		}
+	}
+	if c.ExpectALPN != "" && !tlsURL {
+		add("ExpectALPN requires a tls:// URL")
+	}
+	if c.HealthJSON && !httpURL {
+		add("HealthJSON requires an http or https URL")
+	}
+	for _, s := range c.AcceptStatus {
+		if s < 100 || s > 599 {
+			add("HTTP status %d in AcceptStatus is out of range", s)
+		}
+	}
+	for _, p := range c.Ports {
+		if p < 1 || p > 65535 {
+			add("port %d in Ports is out of range", p)
+		}
+	}
+	switch {
+	case c.Quorum < 0:
+		add("Quorum %d is negative", c.Quorum)
+	case c.Quorum > len(hosts):
+		add("Quorum %d exceeds the %d hosts", c.Quorum, len(hosts))
+	case c.Quorum > 0 && len(c.Ports) > 0:
+		add("Quorum has no effect with Ports")
+	}
+
+	switch c.Network {
+	case "", "tcp", "tcp4", "tcp6":
+	default:
+		add("unsupported Network %q", c.Network)
+	}
+	if c.InterfaceGate < InterfaceGateBlock || c.InterfaceGate > InterfaceGateOff {
+		add("unknown InterfaceGate %v", c.InterfaceGate)
+	}
+	if c.JitterStrategy < JitterAdditive || c.JitterStrategy > JitterDecorrelated {
+		add("unknown JitterStrategy %d", int(c.JitterStrategy))
+	}
+	if c.ProxyProtocol < 0 || c.ProxyProtocol > 2 {
+		add("unsupported PROXY protocol version %d", c.ProxyProtocol)
+	}
+	if c.ConnectProxy != "" {
+		if _, err := c.connectProxy(); err != nil {
+			add("%s", strings.TrimPrefix(err.Error(), "reachable: "))
+		}
+	}
+	if _, err := parseExpected(c.ExpectResolvesTo); err != nil {
+		add("%s", strings.TrimPrefix(err.Error(), "reachable: "))
+	} else if len(c.ExpectResolvesTo) > 0 && c.ConnectProxy != "" {
+		add("ExpectResolvesTo cannot be checked through ConnectProxy")
+	}
+	if c.LocalPortMin != 0 || c.LocalPortMax != 0 {
+		if c.LocalPortMin < 1 || c.LocalPortMax > 65535 || c.LocalPortMax < c.LocalPortMin {
+			add("local port range %d-%d is invalid", c.LocalPortMin, c.LocalPortMax)
+		}
+	}
+
+	interval := orDefault(c.Interval, defaultInterval())
+	if c.MaxInterval > 0 && c.MaxInterval < interval && c.BackoffFunc == nil {
+		add("MaxInterval %v is below Interval %v", c.MaxInterval, interval)
+	}
+	if c.BackoffMin < 0 {
+		add("BackoffMin %v is negative", c.BackoffMin)
+	}
+	if c.Jitter < 0 {
+		add("Jitter %v is negative", c.Jitter)
+	}
+	if c.Timeout > 0 {
+		if c.ResolveTimeout > c.Timeout {
+			add("ResolveTimeout %v exceeds Timeout %v", c.ResolveTimeout, c.Timeout)
+		}
+		if c.ConnectTimeout > c.Timeout {
+			add("ConnectTimeout %v exceeds Timeout %v", c.ConnectTimeout, c.Timeout)
+		}
+	}
+	if c.LatencySmoothing < 0 || c.LatencySmoothing > 1 {
+		add("LatencySmoothing %v is not within (0,1]", c.LatencySmoothing)
+	}
+	if c.dependencyCycle() {
+		add("DependsOn chain forms a cycle")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{problems}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package reachable
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	cycle := &Checker{Hostport: "a:1"}
+	cycle.DependsOn = &Checker{Hostport: "b:1", DependsOn: cycle}
+
+	tests := []struct {
+		name    string
+		c       *Checker
+		problem string // a substring of the only problem, or "" for none
+	}{
+		{"valid", &Checker{Hostport: "example.com:80"}, ""},
+		{"ping only", &Checker{PingFunc: ok}, ""},
+		{"conn factory only", &Checker{ConnFactory: func(context.Context) (net.Conn, error) { return nil, nil }}, ""},
+		{"captive portal only", &Checker{CaptivePortal: true}, ""},
+		{"interface only", &Checker{InterfaceOnly: true}, ""},
+		{"no host", &Checker{}, "no Hostport or Hostports"},
+		{"bad URL", &Checker{Hostport: "http://[::1"}, "missing ']'"},
+		{"URL without host", &Checker{Hostport: "http:///path"}, "has no host"},
+		{"unsupported scheme", &Checker{Hostport: "gopher://example.com"}, "unsupported scheme"},
+		{"HostHeader without HTTP", &Checker{Hostport: "example.com:80", HostHeader: "x"}, "HostHeader requires"},
+		{"DialHost without HTTP", &Checker{Hostport: "tcp://example.com:80", DialHost: "x"}, "DialHost requires"},
+		{"ExpectALPN without TLS", &Checker{Hostport: "example.com:443", ExpectALPN: "h2"}, "ExpectALPN requires"},
+		{"HealthJSON without HTTP", &Checker{Hostport: "example.com:80", HealthJSON: true}, "HealthJSON requires"},
+		{"AcceptStatus range", &Checker{Hostport: "http://example.com", AcceptStatus: []int{200, 700}}, "700 in AcceptStatus"},
+		{"Ports range", &Checker{Hostport: "example.com", Ports: []int{80, 0}}, "port 0 in Ports"},
+		{"Network", &Checker{Hostport: "example.com:80", Network: "udp"}, "unsupported Network"},
+		{"InterfaceGate", &Checker{Hostport: "example.com:80", InterfaceGate: InterfaceGateOff + 1}, "unknown InterfaceGate"},
+		{"JitterStrategy", &Checker{Hostport: "example.com:80", JitterStrategy: -1}, "unknown JitterStrategy"},
+		{"ProxyProtocol", &Checker{Hostport: "example.com:80", ProxyProtocol: 3}, "PROXY protocol version 3"},
+		{"ConnectProxy", &Checker{Hostport: "example.com:80", ConnectProxy: "socks5://proxy:1080"}, "is not an http or https URL"},
+		{"ExpectResolvesTo entry", &Checker{Hostport: "example.com:80", ExpectResolvesTo: []string{"nope"}}, "not an IP address or CIDR"},
+		{"ExpectResolvesTo with proxy", &Checker{Hostport: "example.com:80", ExpectResolvesTo: []string{"10.0.0.0/8"}, ConnectProxy: "http://proxy:3128"}, "through ConnectProxy"},
+		{"local port range", &Checker{Hostport: "example.com:80", LocalPortMin: 50000, LocalPortMax: 40000}, "local port range"},
+		{"MaxInterval", &Checker{Hostport: "example.com:80", Interval: time.Minute, MaxInterval: time.Second}, "below Interval"},
+		{"BackoffMin", &Checker{Hostport: "example.com:80", BackoffMin: -time.Second}, "BackoffMin"},
+		{"Jitter", &Checker{Hostport: "example.com:80", Jitter: -time.Second}, "Jitter"},
+		{"ResolveTimeout", &Checker{Hostport: "example.com:80", Timeout: time.Second, ResolveTimeout: time.Minute}, "ResolveTimeout"},
+		{"ConnectTimeout", &Checker{Hostport: "example.com:80", Timeout: time.Second, ConnectTimeout: time.Minute}, "ConnectTimeout"},
+		{"LatencySmoothing", &Checker{Hostport: "example.com:80", LatencySmoothing: 2}, "LatencySmoothing"},
+		{"DependsOn cycle", cycle, "cycle"},
+	}
+	for _, tt := range tests {
+		err := tt.c.Validate()
+		if tt.problem == "" {
+			if err != nil {
+				t.Errorf("%s: Validate() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) || !errors.Is(err, ErrConfig) {
+			t.Errorf("%s: Validate() = %v, want a *ValidationError matching ErrConfig", tt.name, err)
+			continue
+		}
+		if len(verr.Problems) != 1 || !strings.Contains(verr.Problems[0], tt.problem) {
+			t.Errorf("%s: problems %q, want one containing %q", tt.name, verr.Problems, tt.problem)
+		}
+	}
+}
+
+func TestValidateListsEveryProblem(t *testing.T) {
+	c := &Checker{Hostport: "example.com:80", Network: "udp", Jitter: -1, LatencySmoothing: 2}
+	var verr *ValidationError
+	if err := c.Validate(); !errors.As(err, &verr) || len(verr.Problems) != 3 {
+		t.Errorf("Validate() = %v, want 3 problems", err)
+	}
+}
+
+func TestInvalidConfigFailsChecks(t *testing.T) {
+	c := &Checker{SkipInterfaceCheck: true}
+	if st := c.Step(); st.State != Down || !errors.Is(st.Err, ErrConfig) {
+		t.Errorf("Step() = %v, %v, want down with ErrConfig", st.State, st.Err)
+	}
+
+	c = &Checker{ConnFactory: func(context.Context) (net.Conn, error) {
+		a, b := net.Pipe()
+		b.Close()
+		return a, nil
+	}, SkipInterfaceCheck: true}
+	if st := c.Step(); errors.Is(st.Err, ErrConfig) {
+		t.Errorf("ConnFactory Checker failed validation: %v", st.Err)
+	}
+}
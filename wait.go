@@ -0,0 +1,98 @@
+package reachable
+
+import "context"
+
+// WaitReachable blocks until the host is reachable (Up or Degraded), returning
+// immediately if it already is. It returns ctx.Err() if ctx is done first, and
+// ErrNotRunning if the Checker is not running or stops while waiting.
+func (c *Checker) WaitReachable(ctx context.Context) error {
+	return c.waitFor(ctx, true)
+}
+
+// WaitUnreachable is like WaitReachable, but waits for the host to be Down.
+func (c *Checker) WaitUnreachable(ctx context.Context) error {
+	return c.waitFor(ctx, false)
+}
+
+func (c *Checker) waitFor(ctx context.Context, reachable bool) error {
+	// subscribe before reading the state so no transition is missed
+	ch, unsubscribe := c.subscribe()
+	defer unsubscribe()
+	c.mu.Lock()
+	running, state := c.running, c.status.State
+	c.mu.Unlock()
+	if !running {
+		return ErrNotRunning
+	}
+	if state != Unknown && state.reachable() == reachable {
+		return nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s, ok := <-ch:
+			if !ok {
+				return ErrNotRunning
+			}
+			if s.reachable() == reachable {
+				return nil
+			}
+		}
+	}
+}
+
+// WaitReachable blocks until the default Checker finds the host reachable. It
+// returns ErrNotRunning if Start has not been called.
+func WaitReachable(ctx context.Context) error {
+	return singleton.WaitReachable(ctx)
+}
+
+// WaitUnreachable blocks until the default Checker finds the host
+// unreachable. It returns ErrNotRunning if Start has not been called.
+func WaitUnreachable(ctx context.Context) error {
+	return singleton.WaitUnreachable(ctx)
+}
+
+// WaitFirstCheck blocks until the Checker has completed its first check since
+// Start, whatever its result, so that Status and the notified state reflect a
+// real observation rather than the startup default. It returns immediately if
+// a check has already completed, ctx.Err() if ctx is done first, and
+// ErrNotRunning if the Checker is not running or stops while waiting.
+func (c *Checker) WaitFirstCheck(ctx context.Context) error {
+	// subscribe first, as its channel is closed when the Checker stops
+	ch, unsubscribe := c.subscribe()
+	defer unsubscribe()
+	c.mu.Lock()
+	running, checks := c.running, c.stats.Checks
+	if c.checked == nil {
+		c.checked = make(chan struct{})
+	}
+	checked := c.checked
+	c.mu.Unlock()
+	if !running {
+		return ErrNotRunning
+	}
+	if checks > 0 {
+		return nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-checked:
+			return nil
+		case _, ok := <-ch:
+			if !ok {
+				return ErrNotRunning
+			}
+		}
+	}
+}
+
+// WaitFirstCheck blocks until the default Checker has completed its first
+// check, after which NetworkIsReachable reports an observed state. It returns
+// ErrNotRunning if Start has not been called.
+func WaitFirstCheck(ctx context.Context) error {
+	return singleton.WaitFirstCheck(ctx)
+}
@@ -0,0 +1,217 @@
+// Package webhook provides a reachable Notifier that POSTs a small JSON
+// payload to an HTTP endpoint whenever reachability changes. This is intended
+// for wiring a Checker into ops alerting without pulling HTTP client code into
+// the core package.
+//
+//    h := &webhook.Hook{
+//        URL:      "https://alerts.example.com/hooks/reachable",
+//        Hostport: "google.com:443",
+//    }
+//    c := reachable.Checker{
+//        Hostport: "google.com:443",
+//        Notifier: h.Notify,
+//    }
+//    c.Start()
+//    defer h.Close()
+//
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultTimeout is the per-request timeout used when Hook.Timeout is unset.
+	DefaultTimeout = time.Second * 10
+
+	// DefaultRetries is the number of additional attempts made when a delivery
+	// fails and Hook.Retries is unset.
+	DefaultRetries = 3
+
+	// DefaultRetryDelay is the wait before the first retry. It doubles after
+	// each failed attempt. A payload still being retried is given up on as
+	// soon as a newer one is queued, which supersedes it.
+	DefaultRetryDelay = time.Second
+
+	// QueueSize is the number of undelivered payloads a Hook will buffer before
+	// it starts dropping new ones.
+	QueueSize = 16
+)
+
+// Payload is the JSON body sent to the webhook URL.
+type Payload struct {
+	Hostport string            `json:"hostport"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	State    string            `json:"state"`
+	At       time.Time         `json:"at"`
+}
+
+// Hook POSTs reachability changes to URL. Its Notify method can be used
+// directly as a reachable.Checker Notifier. Deliveries happen in order on a
+// background goroutine, so Notify never blocks the polling loop, which runs
+// until Close is called.
+type Hook struct {
+	// URL to POST payloads to.
+	URL string
+
+	// Hostport is reported in the payload to identify the monitored host.
+	Hostport string
+
+	// Tags is reported in the payload for routing the alert, e.g. the
+	// monitored Checker's Tags.
+	Tags map[string]string
+
+	// Client is used to send requests. If nil, a new client is made on first
+	// use and shared by every delivery.
+	Client *http.Client
+
+	// Timeout for each delivery attempt, applied whether or not Client is
+	// set. If zero or negative, uses DefaultTimeout.
+	Timeout time.Duration
+
+	// Retries is the number of additional attempts after a failed delivery.
+	// If zero, uses DefaultRetries. Set negative to disable retries.
+	Retries int
+
+	// ErrorLog, if set, is called when a payload could not be delivered after
+	// all retries, or was dropped because the queue was full.
+	ErrorLog func(error)
+
+	once   sync.Once
+	client *http.Client
+	queue  chan Payload
+	ctx    context.Context // done once Close is called
+	cancel context.CancelFunc
+	done   chan struct{} // closed when run exits
+}
+
+// start sets up the Hook and starts the delivery goroutine on first use.
+func (h *Hook) start() {
+	h.once.Do(func() {
+		h.client = h.Client
+		if h.client == nil {
+			h.client = &http.Client{}
+		}
+		h.queue = make(chan Payload, QueueSize)
+		h.ctx, h.cancel = context.WithCancel(context.Background())
+		h.done = make(chan struct{})
+		go h.run()
+	})
+}
+
+// Close stops delivery, abandoning any request or retry in progress and the
+// payloads still queued, and waits for the background goroutine to exit.
+// Notify does nothing once the Hook is closed.
+func (h *Hook) Close() {
+	h.start()
+	h.cancel()
+	<-h.done
+}
+
+// Notify queues a payload for delivery. It has the signature of a
+// reachable.Checker Notifier.
+func (h *Hook) Notify(reachable bool) {
+	h.start()
+	if h.ctx.Err() != nil {
+		return
+	}
+
+	p := Payload{Hostport: h.Hostport, Tags: h.Tags, State: "down", At: time.Now()}
+	if reachable {
+		p.State = "up"
+	}
+	select {
+	case h.queue <- p:
+	default:
+		h.logError(fmt.Errorf("webhook: queue full, dropped %s notification", p.State))
+	}
+}
+
+func (h *Hook) run() {
+	defer close(h.done)
+	var next *Payload
+	for {
+		p := next
+		if p == nil {
+			select {
+			case <-h.ctx.Done():
+				return
+			case q := <-h.queue:
+				p = &q
+			}
+		}
+		var err error
+		next, err = h.deliver(*p)
+		if err != nil && h.ctx.Err() == nil {
+			h.logError(err)
+		}
+	}
+}
+
+// deliver sends p, retrying on failure, and returns a newer payload that
+// arrived while waiting to retry, which supersedes p.
+func (h *Hook) deliver(p Payload) (*Payload, error) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := h.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	delay := DefaultRetryDelay
+	for attempt := 0; ; attempt++ {
+		err = h.post(body)
+		if err == nil || attempt >= retries || h.ctx.Err() != nil {
+			return nil, err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-h.ctx.Done():
+			t.Stop()
+			return nil, err
+		case q := <-h.queue:
+			t.Stop()
+			return &q, fmt.Errorf("webhook: %s notification superseded before delivery: %w", p.State, err)
+		case <-t.C:
+		}
+		delay *= 2
+	}
+}
+
+func (h *Hook) post(body []byte) error {
+	timeout := h.Timeout
+	if timeout <= time.Duration(0) {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s returned %s", h.URL, resp.Status)
+	}
+	return nil
+}
+
+func (h *Hook) logError(err error) {
+	if h.ErrorLog != nil {
+		h.ErrorLog(err)
+	}
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// endpoint records delivered payload states and answers each POST with the
+// status returned by respond.
+type endpoint struct {
+	mu      sync.Mutex
+	states  []string
+	respond func(Payload) int
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var p Payload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	e.mu.Lock()
+	e.states = append(e.states, p.State)
+	e.mu.Unlock()
+	w.WriteHeader(e.respond(p))
+}
+
+func (e *endpoint) delivered() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.states...)
+}
+
+func TestNotify(t *testing.T) {
+	e := &endpoint{respond: func(Payload) int { return http.StatusOK }}
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	h := &Hook{URL: srv.URL, Hostport: "example.com:80"}
+	h.Notify(false)
+	h.Notify(true)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(e.delivered()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.Close()
+	if got := e.delivered(); len(got) != 2 || got[0] != "down" || got[1] != "up" {
+		t.Errorf("delivered %q, want [down up]", got)
+	}
+}
+
+func TestCloseDuringRetry(t *testing.T) {
+	defer func(d time.Duration) { DefaultRetryDelay = d }(DefaultRetryDelay)
+	DefaultRetryDelay = time.Hour
+
+	e := &endpoint{respond: func(Payload) int { return http.StatusServiceUnavailable }}
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	h := &Hook{URL: srv.URL}
+	h.Notify(false)
+	for len(e.delivered()) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	done := make(chan struct{})
+	go func() {
+		h.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not interrupt the retry wait")
+	}
+	h.Notify(true)
+	if got := e.delivered(); len(got) != 1 {
+		t.Errorf("delivered %q after Close, want only the first attempt", got)
+	}
+}
+
+func TestNewerPayloadSupersedesRetry(t *testing.T) {
+	defer func(d time.Duration) { DefaultRetryDelay = d }(DefaultRetryDelay)
+	DefaultRetryDelay = time.Hour
+
+	e := &endpoint{respond: func(p Payload) int {
+		if p.State == "down" {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	}}
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	var errs []error
+	var mu sync.Mutex
+	h := &Hook{URL: srv.URL, ErrorLog: func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}}
+	defer h.Close()
+	h.Notify(false)
+	for len(e.delivered()) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.Notify(true)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(e.delivered()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := e.delivered(); len(got) != 2 || got[1] != "up" {
+		t.Fatalf("delivered %q, want the up payload without waiting for the retry", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 {
+		t.Errorf("ErrorLog got %v, want one superseded error", errs)
+	}
+}
+
+func TestTimeoutWithClient(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	errs := make(chan error, 1)
+	h := &Hook{
+		URL:      srv.URL,
+		Client:   &http.Client{},
+		Timeout:  50 * time.Millisecond,
+		Retries:  -1,
+		ErrorLog: func(err error) { errs <- err },
+	}
+	defer h.Close()
+	h.Notify(true)
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("delivery failed with %v, want the Timeout deadline", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout was not applied to a Hook with a Client")
+	}
+}
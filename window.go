@@ -0,0 +1,41 @@
+package reachable
+
+import "time"
+
+// TimeWindow is a daily recurring span of wall-clock time, such as a nightly
+// maintenance window, expressed as offsets from midnight in Location. If End
+// is before Start the window wraps past midnight, so {Start: 23h, End: 1h}
+// covers 23:00 through 00:59.
+type TimeWindow struct {
+	Start time.Duration
+	End   time.Duration
+
+	// Location is the time zone the offsets are interpreted in. If nil,
+	// time.Local is used.
+	Location *time.Location
+}
+
+// Contains returns true if t falls within the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	loc := w.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	h, m, s := t.Clock()
+	offset := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+func (c *Checker) inSuppressWindow(t time.Time) bool {
+	for _, w := range c.SuppressWindows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}